@@ -5,4 +5,8 @@ type Document struct {
 	Url     string
 	Content string
 	Score   float64
+	// Language is the ISO 639-1 code of the language of the content (e.g. `en`), detected at index time if empty.
+	Language string
+	// LanguageConfidence is the confidence of the language detection, between 0 and 1.
+	LanguageConfidence float64
 }
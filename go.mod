@@ -2,7 +2,10 @@ module go4search
 
 go 1.21
 
-require github.com/gofiber/fiber v1.14.6
+require (
+	github.com/blevesearch/snowballstem v0.9.0
+	github.com/gofiber/fiber v1.14.6
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -36,6 +39,6 @@ require (
 	github.com/valyala/tcplisten v1.0.0 // indirect
 	golang.org/x/exp v0.0.0-20221106115401-f9659909a136 // indirect
 	golang.org/x/sys v0.15.0 // indirect
-	golang.org/x/text v0.13.0 // indirect
+	golang.org/x/text v0.13.0
 	google.golang.org/protobuf v1.33.0 // indirect
 )
@@ -3,12 +3,12 @@ package main
 import (
 	"bufio"
 	"fmt"
-	"math"
 
 	"net/http"
 	_ "net/http/pprof"
 
 	"os"
+	"strconv"
 	"strings"
 
 	documents "go4search/documents"
@@ -18,7 +18,7 @@ import (
 	"github.com/gofiber/fiber"
 )
 
-var SearchEngine searchengine.SearchEngine
+var SearchEngine *searchengine.SearchEngine
 
 func init() {
 	// docs := ParseFetchAndReturnDocuments()
@@ -58,42 +58,76 @@ func init() {
 	}
 	SaveDocsAsCsv(docs, "data/result.csv")
 
-	// initialize the tokenizer
-	nlp.Init_Tokenizer()
-
-	useTokenizer := true
-	index, sbf := searchengine.BuildInvertedIndex(docs, useTokenizer)
-	docLength := 0.
-	count := 0
-	maxThreshold := math.MaxFloat64 - 100
-	for _, doc := range docs {
-		currentDocLength := float64(len(doc.Content))
-		// check if docLength + currentDocLength is too large to avoid overflow
-		if docLength+currentDocLength > maxThreshold {
-			break
-		}
-
-		// increase the docLength
-		docLength += currentDocLength
-		count++
+	// initialize the tokenizer, see nlp.TokenizerConfigFromEnv to load it from a local file
+	if err := nlp.Init_Tokenizer(); err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to initialize the tokenizer:", err)
+		fmt.Fprintf(os.Stderr, "Set %s to a local %s to run without network access\n", nlp.ENV_TOKENIZER_PATH, nlp.TOKENIZER_FILE)
+		os.Exit(1)
 	}
+
 	// to avoid division by zero
-	if count == 0 {
+	if len(docs) == 0 {
 		panic("No documents to index")
 	}
-	countF := float64(count)
 
-	// initialize the search engine
-	SearchEngine = searchengine.SearchEngine{
-		Index:         index,
-		Documents:     docs,
-		TotalDocCount: countF,
-		TotalDocLen:   docLength,
-		AvgDocLength:  docLength / countF,
-		K1:            1.2,
-		B:             0.75,
-		Bloomfilter:   sbf,
+	// the stopwords of the index, see nlp.StopwordConfigFromEnv to use a custom list or to disable them
+	stopwordConfig := nlp.StopwordConfigFromEnv()
+	stopwordFilter, err := stopwordConfig.Filter()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to load the stopwords:", err)
+		os.Exit(1)
+	}
+
+	// the indexing options are all set before the documents are indexed, once
+	options := searchengine.EngineOptions{KeepPhraseStopwords: stopwordConfig.KeepInPhrases}
+	// index the subword pieces of the words in a secondary field, see searchengine.FIELD_SUBWORD
+	if searchengine.SubwordFieldFromEnv() {
+		options.Fields = append(options.Fields, searchengine.FieldConfig{
+			Name:     searchengine.FIELD_SUBWORD,
+			Analyzer: nlp.NewSubwordAnalyzer(),
+			Weight:   searchengine.SUBWORD_FIELD_WEIGHT,
+		})
+	}
+	// expand the queries with synonyms, or index them, see nlp.SynonymConfigFromEnv
+	synonymConfig := nlp.SynonymConfigFromEnv()
+	synonyms, err := synonymConfig.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to load the synonyms:", err)
+		os.Exit(1)
+	}
+	if synonymConfig.Index {
+		options.IndexSynonyms = synonyms
+	} else {
+		options.QuerySynonyms = synonyms
+	}
+	// expand the queries with the terms of their top documents, see searchengine.FeedbackConfigFromEnv
+	if options.Feedback = searchengine.FeedbackConfigFromEnv(); options.Feedback != nil {
+		if err := options.Feedback.Validate(); err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to enable the relevance feedback:", err)
+			os.Exit(1)
+		}
+	}
+	// index the phonetic codes of the words for the phonetic search mode, see searchengine.FIELD_PHONETIC
+	if encoder := searchengine.PhoneticEncoderFromEnv(); encoder != "" {
+		field, err := searchengine.PhoneticFieldConfig(encoder)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to enable the phonetic search:", err)
+			os.Exit(1)
+		}
+		options.Fields = append(options.Fields, field)
+	}
+
+	// initialize the search engine, the documents and the queries are analyzed with nlp.NewStandardAnalyzer()
+	SearchEngine = searchengine.NewSearchEngine(docs, map[string]*nlp.Analyzer{
+		searchengine.FIELD_CONTENT: nlp.NewStandardAnalyzer().WithStopwords(stopwordFilter),
+	}, options)
+	// the index of the substring search mode, see searchengine.SubstringIndexFromEnv
+	substringIndex, err := searchengine.SubstringIndexFromEnv()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to enable the substring search:", err)
+		os.Exit(1)
 	}
+	SearchEngine.EnableSubstringSearch(substringIndex)
 }
 
 func main() {
@@ -110,9 +144,54 @@ func main() {
 			c.Status(400).Send("No query provided")
 			return
 		}
-		results := SearchEngine.Search(query, 20)
+		if c.Query("mode") == "substring" {
+			maxErrors, _ := strconv.Atoi(c.Query("errors"))
+			if maxErrors <= 0 {
+				c.JSON(SearchEngine.SubstringSearch(query, 20))
+				return
+			}
+			results, err := SearchEngine.ApproximateSubstringSearch(query, maxErrors, 20)
+			if err != nil {
+				c.Status(400).Send(err.Error())
+				return
+			}
+			c.JSON(results)
+			return
+		}
+		if language := c.Query("lang"); language != "" {
+			query += " lang:" + language
+		}
+		if c.Query("mode") == "common" {
+			results, err := SearchEngine.CommonTermsSearch(query, searchengine.DEFAULT_COMMON_TERMS_CUTOFF, 20)
+			if err != nil {
+				c.Status(400).Send(err.Error())
+				return
+			}
+			c.JSON(results)
+			return
+		}
+		if c.Query("mode") == "phonetic" {
+			results, err := SearchEngine.PhoneticSearch(query, 20)
+			if err != nil {
+				c.Status(400).Send(err.Error())
+				return
+			}
+			c.JSON(results)
+			return
+		}
+		results, err := SearchEngine.SearchQuery(query, 20)
+		if err != nil {
+			c.Status(400).Send(err.Error())
+			return
+		}
 		c.JSON(results)
 	})
+	app.Get("/languages", func(c *fiber.Ctx) {
+		c.JSON(SearchEngine.LanguageStatistics())
+	})
+	app.Get("/stopwords", func(c *fiber.Ctx) {
+		c.JSON(SearchEngine.StopwordCandidates(searchengine.DEFAULT_COMMON_TERMS_CUTOFF, 100))
+	})
 
 	// run endless loop to accept search queries from the user
 	for {
@@ -122,8 +201,17 @@ func main() {
 		if query == "" {
 			continue
 		}
-		results := SearchEngine.Search(query, 20)
+		results, err := SearchEngine.SearchQuery(query, 20)
+		if err != nil {
+			fmt.Println("Invalid query:", err)
+			continue
+		}
 		fmt.Printf("%d results for query '%s':\n", len(results), query)
+		if len(results) == 0 {
+			if corrected, ok := SearchEngine.DidYouMean(query); ok {
+				fmt.Printf("Did you mean '%s'?\n", corrected)
+			}
+		}
 		for _, result := range results {
 			fmt.Printf("- %s (score=%.2f)\n", result.Content, result.Score)
 		}
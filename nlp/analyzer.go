@@ -0,0 +1,313 @@
+package nlp
+
+import (
+	"strings"
+)
+
+// Token is a term produced by an Analyzer.
+type Token struct {
+	Term string
+	// Position is the position of the token in the text. The tokens added by a filter in place of another one
+	// (e.g. synonyms) share its position, and the positions of the removed tokens (e.g. stopwords) are left as gaps.
+	Position int
+}
+
+// CharFilter rewrites the text before it is tokenized, e.g. to lowercase it or to strip markup.
+type CharFilter interface {
+	Filter(text string) string
+}
+
+// Tokenizer splits a text into tokens.
+type Tokenizer interface {
+	Tokenize(text string) []Token
+}
+
+// TokenFilter rewrites the tokens produced by the tokenizer: it can change, remove or add tokens.
+type TokenFilter interface {
+	Filter(tokens []Token) []Token
+}
+
+// LanguageTokenFilter is a token filter depending on the language of the text, e.g. a stopword filter.
+// The analyzer passes it the language of the text, detected once for all the filters if it is not known.
+type LanguageTokenFilter interface {
+	TokenFilter
+	FilterLanguage(tokens []Token, language string) []Token
+}
+
+// CharFilterFunc turns a function into a CharFilter.
+type CharFilterFunc func(text string) string
+
+func (f CharFilterFunc) Filter(text string) string {
+	return f(text)
+}
+
+// TokenFilterFunc turns a function into a TokenFilter.
+type TokenFilterFunc func(tokens []Token) []Token
+
+func (f TokenFilterFunc) Filter(tokens []Token) []Token {
+	return f(tokens)
+}
+
+// LowercaseFilter is a char filter lowercasing the text.
+var LowercaseFilter = CharFilterFunc(strings.ToLower)
+
+// Analyzer turns a text into the terms of the index: the char filters are applied to the text in order,
+// then the text is split by the tokenizer, and the token filters are applied to the tokens in order.
+//
+// The same analyzer must be used to index a field and to search it, so that the terms of the query
+// match the terms of the documents.
+type Analyzer struct {
+	CharFilters  []CharFilter
+	Tokenizer    Tokenizer
+	TokenFilters []TokenFilter
+}
+
+func NewAnalyzer(charFilters []CharFilter, tokenizer Tokenizer, tokenFilters ...TokenFilter) *Analyzer {
+	return &Analyzer{CharFilters: charFilters, Tokenizer: tokenizer, TokenFilters: tokenFilters}
+}
+
+/**
+ * Create the default analyzer: normalize the text (see NormalizationFilters), split the Hangul words into their
+ * morphemes (see KoreanTokenizer), the Chinese and Japanese text into bigrams (see CJKTokenizer), and the rest of
+ * the text with the subword tokenizer (see NewSubwordTokenizer), merge the subword pieces back into words and drop the
+ * special tokens (e.g. `[UNK]`), remove the stopwords of the detected language, and stem the remaining words.
+ *
+ * @return *Analyzer
+ */
+func NewStandardAnalyzer() *Analyzer {
+	tokenizer := NewKoreanTokenizer(NewCJKTokenizer(NewSubwordTokenizer(), false))
+	return NewAnalyzer(NormalizationFilters(false), tokenizer, WordPieceMergeFilter{}, SpecialTokenFilter{}, NewStopwordFilter(""), NewStemmerFilter(""))
+}
+
+/**
+ * Create an analyzer lowercasing the text and splitting it on white spaces, without any other filter.
+ *
+ * @return *Analyzer
+ */
+func NewWhitespaceAnalyzer() *Analyzer {
+	return NewAnalyzer([]CharFilter{LowercaseFilter}, WhitespaceTokenizer{})
+}
+
+/**
+ * Return a copy of the analyzer with another stopword filter. The filter replaces the stopword filters of the analyzer,
+ * or is inserted before the stemmer filters if it has none, so that the stopwords are matched before being stemmed.
+ *
+ * @param filter The stopword filter, nil to remove the stopword filters
+ * @return *Analyzer
+ */
+func (a *Analyzer) WithStopwords(filter *StopwordFilter) *Analyzer {
+	var tokenFilter TokenFilter
+	if filter != nil {
+		tokenFilter = filter
+	}
+	return a.withFilter(tokenFilter, func(f TokenFilter) bool {
+		_, ok := f.(*StopwordFilter)
+		return ok
+	}, func(f TokenFilter) bool {
+		_, ok := f.(*StemmerFilter)
+		return ok
+	})
+}
+
+/**
+ * Return a copy of the analyzer with another synonym filter. The filter replaces the synonym filters of the analyzer,
+ * or is inserted before the stopword and the stemmer filters if it has none, so that the synonyms are matched as
+ * written, and so that the synonyms are stemmed as the other words.
+ *
+ * @param filter The synonym filter, nil to remove the synonym filters
+ * @return *Analyzer
+ */
+func (a *Analyzer) WithSynonyms(filter *SynonymFilter) *Analyzer {
+	var tokenFilter TokenFilter
+	if filter != nil {
+		tokenFilter = filter
+	}
+	return a.withFilter(tokenFilter, func(f TokenFilter) bool {
+		_, ok := f.(*SynonymFilter)
+		return ok
+	}, func(f TokenFilter) bool {
+		_, isStopwordFilter := f.(*StopwordFilter)
+		_, isStemmerFilter := f.(*StemmerFilter)
+		return isStopwordFilter || isStemmerFilter
+	})
+}
+
+// withFilter returns a copy of the analyzer where the token filter replaces the filters matching replaces,
+// or is inserted before the first filter matching before, or is appended. A nil filter removes the replaced filters.
+func (a *Analyzer) withFilter(filter TokenFilter, replaces func(TokenFilter) bool, before func(TokenFilter) bool) *Analyzer {
+	filters := make([]TokenFilter, 0, len(a.TokenFilters)+1)
+	inserted := filter == nil
+	for _, tokenFilter := range a.TokenFilters {
+		isReplaced := replaces(tokenFilter)
+		if !inserted && (isReplaced || before(tokenFilter)) {
+			filters = append(filters, filter)
+			inserted = true
+		}
+		if !isReplaced {
+			filters = append(filters, tokenFilter)
+		}
+	}
+	if !inserted {
+		filters = append(filters, filter)
+	}
+	return &Analyzer{CharFilters: a.CharFilters, Tokenizer: a.Tokenizer, TokenFilters: filters}
+}
+
+/**
+ * Analyze a text, detecting its language if a token filter depends on it.
+ *
+ * @param text string
+ * @return []Token
+ */
+func (a *Analyzer) Analyze(text string) []Token {
+	return a.AnalyzeLanguage(text, "")
+}
+
+/**
+ * Analyze a text written in the given language.
+ *
+ * @param text string
+ * @param language The language of the text as returned by DetectLanguage, detected if empty
+ * @return []Token
+ */
+func (a *Analyzer) AnalyzeLanguage(text string, language string) []Token {
+	tokens, _ := a.analyze(text, language, false)
+	return tokens
+}
+
+/**
+ * Analyze a text written in the given language, and also return its tokens without the stemmer filters and with the
+ * stopwords, so that the words can be indexed both as terms and as they are written (e.g. for the wildcard patterns,
+ * the suggestions and the common terms). The text is normalized and tokenized once.
+ *
+ * @param text string
+ * @param language The language of the text as returned by DetectLanguage, detected if empty
+ * @return ([]Token, []Token) the tokens, and the unstemmed tokens, nil if the analyzer neither stems nor removes stopwords
+ */
+func (a *Analyzer) AnalyzeLanguageUnstemmed(text string, language string) ([]Token, []Token) {
+	return a.analyze(text, language, true)
+}
+
+/**
+ * Return a copy of the analyzer without its stemmer filters, and keeping the stopwords. It analyzes the texts as
+ * the unstemmed tokens of AnalyzeLanguageUnstemmed.
+ *
+ * @return *Analyzer
+ */
+func (a *Analyzer) Unstemmed() *Analyzer {
+	filters := make([]TokenFilter, 0, len(a.TokenFilters))
+	for _, filter := range a.TokenFilters {
+		if unstemmedFilter := unstemmedFilter(filter); unstemmedFilter != nil {
+			filters = append(filters, unstemmedFilter)
+		}
+	}
+	return &Analyzer{CharFilters: a.CharFilters, Tokenizer: a.Tokenizer, TokenFilters: filters}
+}
+
+// keepStopwordsFilter only removes the tokens made of punctuation, as the stopword filters do.
+var keepStopwordsFilter = NewCustomStopwordFilter(nil)
+
+// unstemmedFilter returns the filter applied to the unstemmed tokens in place of a filter: nil for a stemmer filter,
+// keepStopwordsFilter for a stopword filter, and the filter itself otherwise.
+func unstemmedFilter(filter TokenFilter) TokenFilter {
+	switch filter.(type) {
+	case *StemmerFilter:
+		return nil
+	case *StopwordFilter:
+		return keepStopwordsFilter
+	}
+	return filter
+}
+
+// Stems returns whether the analyzer has a stemmer filter.
+func (a *Analyzer) Stems() bool {
+	for _, filter := range a.TokenFilters {
+		if _, ok := filter.(*StemmerFilter); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RemovesStopwords returns whether the analyzer has a stopword filter removing words, i.e. the built-in list of a
+// language or a non-empty custom list.
+func (a *Analyzer) RemovesStopwords() bool {
+	for _, filter := range a.TokenFilters {
+		if stopwords, ok := filter.(*StopwordFilter); ok && stopwords.removes() {
+			return true
+		}
+	}
+	return false
+}
+
+// splitsUnstemmed returns whether the unstemmed tokens differ from the tokens from the filter on, i.e. whether it is
+// a stemmer filter or a stopword filter removing words.
+func splitsUnstemmed(filter TokenFilter) bool {
+	switch f := filter.(type) {
+	case *StemmerFilter:
+		return true
+	case *StopwordFilter:
+		return f.removes()
+	}
+	return false
+}
+
+// analyze runs the analyzer, and also the analyzer without its stemmer filters and keeping the stopwords if unstemmed
+// is set (see Unstemmed). Both share the tokens up to the first stemmer or stopword filter.
+func (a *Analyzer) analyze(text string, language string, unstemmed bool) ([]Token, []Token) {
+	text = a.Normalize(text)
+	tokens := a.Tokenizer.Tokenize(text)
+	var unstemmedTokens []Token
+
+	detected := language != ""
+	apply := func(filter TokenFilter, tokens []Token) []Token {
+		if filter == nil {
+			return tokens
+		}
+		languageFilter, ok := filter.(LanguageTokenFilter)
+		if !ok {
+			return filter.Filter(tokens)
+		}
+		if !detected {
+			if detectedLanguage, exists := DetectLanguage(text); exists {
+				language = detectedLanguage
+			}
+			detected = true
+		}
+		return languageFilter.FilterLanguage(tokens, language)
+	}
+	split := false
+	for _, filter := range a.TokenFilters {
+		if unstemmed && !split && splitsUnstemmed(filter) {
+			unstemmedTokens, split = tokens, true
+		}
+		if split {
+			unstemmedTokens = apply(unstemmedFilter(filter), unstemmedTokens)
+		}
+		tokens = apply(filter, tokens)
+	}
+	return tokens, unstemmedTokens
+}
+
+/**
+ * Apply the char filters of the analyzer to a text, without tokenizing it.
+ * It is meant for the query terms that are not analyzed, e.g. wildcard patterns.
+ *
+ * @param text string
+ * @return string
+ */
+func (a *Analyzer) Normalize(text string) string {
+	for _, filter := range a.CharFilters {
+		text = filter.Filter(text)
+	}
+	return text
+}
+
+// Terms returns the terms of the tokens.
+func Terms(tokens []Token) []string {
+	terms := make([]string, len(tokens))
+	for i, token := range tokens {
+		terms[i] = token.Term
+	}
+	return terms
+}
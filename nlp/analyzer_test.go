@@ -0,0 +1,227 @@
+package nlp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bbalet/stopwords"
+)
+
+func TestStopwordFilter(t *testing.T) {
+	// Define the input and expected output
+	input := "This is a test sentence with some stopwords."
+	expectedOutput := strings.Fields(stopwords.CleanString(input, "english", true))
+	// the filter only removes tokens, the punctuation is kept in the remaining ones
+	expectedOutput[len(expectedOutput)-1] += "."
+
+	// Call the function to be tested
+	analyzer := NewAnalyzer([]CharFilter{LowercaseFilter}, WhitespaceTokenizer{}, NewStopwordFilter(""))
+	actualOutput := Terms(analyzer.Analyze(input))
+
+	// Compare the actual output with the expected output
+	if !reflect.DeepEqual(actualOutput, expectedOutput) {
+		t.Errorf("Expected %v, but got %v", expectedOutput, actualOutput)
+	}
+}
+
+func TestStopwordFilterLanguage(t *testing.T) {
+	tokens := WhitespaceTokenizer{}.Tokenize("le chat est sur la table")
+
+	// the language given to the analyzer is used, unless the filter has its own
+	if terms := Terms(NewStopwordFilter("").FilterLanguage(tokens, "french")); !reflect.DeepEqual(terms, []string{"chat", "table"}) {
+		t.Errorf("Expected the french stopwords to be removed, got %v", terms)
+	}
+	if terms := Terms(NewStopwordFilter("en").FilterLanguage(tokens, "french")); len(terms) != len(tokens) {
+		t.Errorf("Expected no english stopword to be removed, got %v", terms)
+	}
+	if terms := Terms(NewStopwordFilter("").FilterLanguage(tokens, "")); len(terms) != len(tokens) {
+		t.Errorf("Expected no stopword to be removed without a language, got %v", terms)
+	}
+}
+
+func TestBuiltinStopwords(t *testing.T) {
+	files, _ := filepath.Glob("stopwords/*.txt")
+	if len(files) == 0 {
+		t.Fatal("Expected the built-in stopword lists")
+	}
+	for _, file := range files {
+		if code := strings.TrimSuffix(filepath.Base(file), ".txt"); len(builtinStopwords(code)) == 0 {
+			t.Errorf("Expected the %s stopwords", code)
+		}
+	}
+	if words := builtinStopwords("ko"); len(words) != 0 {
+		t.Errorf("Expected no korean stopwords, got %d", len(words))
+	}
+
+	// the embedded lists are the ones of the stopwords package
+	for _, code := range []string{"en", "fr", "de"} {
+		for word := range builtinStopwords(code) {
+			if cleaned := strings.TrimSpace(stopwords.CleanString(word, code, false)); cleaned == word {
+				t.Errorf("Expected %q to be a %s stopword of the stopwords package", word, code)
+			}
+		}
+	}
+}
+
+func TestCustomStopwordFilter(t *testing.T) {
+	tokens := WhitespaceTokenizer{}.Tokenize("The hobbit , and THE ring")
+
+	// the custom stopwords replace the built-in list, whatever the language
+	if terms := Terms(NewCustomStopwordFilter([]string{"The"}).FilterLanguage(tokens, "english")); !reflect.DeepEqual(terms, []string{"hobbit", "and", "ring"}) {
+		t.Errorf("Expected the custom stopwords to be removed, got %v", terms)
+	}
+	// without stopwords, only the punctuation is removed
+	if terms := Terms(NewCustomStopwordFilter(nil).Filter(tokens)); !reflect.DeepEqual(terms, []string{"The", "hobbit", "and", "THE", "ring"}) {
+		t.Errorf("Expected the punctuation only to be removed, got %v", terms)
+	}
+}
+
+func TestStopwordConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "stopwords.txt")
+	if err := os.WriteFile(file, []byte("# custom stopwords\nhobbit\n\nring  # the one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	analyzer := NewAnalyzer([]CharFilter{LowercaseFilter}, WhitespaceTokenizer{}, NewStopwordFilter(""), NewStemmerFilter("english"))
+	text := "the hobbit and the rings"
+
+	tests := []struct {
+		config   StopwordConfig
+		expected []string
+	}{
+		{StopwordConfig{}, []string{"hobbit", "ring"}},
+		{StopwordConfig{Language: "french"}, []string{"the", "hobbit", "and", "the", "ring"}},
+		{StopwordConfig{Disabled: true}, []string{"the", "hobbit", "and", "the", "ring"}},
+		{StopwordConfig{File: file, Words: []string{"and"}}, []string{"the", "the", "ring"}},
+	}
+	for _, test := range tests {
+		filter, err := test.config.Filter()
+		if err != nil {
+			t.Fatalf("%+v: unexpected error: %v", test.config, err)
+		}
+		// the stopwords are removed before stemming, rings is not a stopword
+		if terms := Terms(analyzer.WithStopwords(filter).AnalyzeLanguage(text, "en")); !reflect.DeepEqual(terms, test.expected) {
+			t.Errorf("%+v: expected %v, got %v", test.config, test.expected, terms)
+		}
+	}
+
+	if _, err := (StopwordConfig{File: filepath.Join(t.TempDir(), "missing.txt")}).Filter(); err == nil {
+		t.Error("Expected an error for a missing stopword file")
+	}
+	if _, err := (StopwordConfig{Language: "klingon"}).Filter(); err == nil {
+		t.Error("Expected an error for an unknown language")
+	}
+}
+
+func TestAnalyzerWithStopwords(t *testing.T) {
+	analyzer := NewAnalyzer([]CharFilter{LowercaseFilter}, WhitespaceTokenizer{}, NewStemmerFilter("english"))
+
+	// the filter is inserted before the stemmer, and the analyzer is left unchanged
+	withStopwords := analyzer.WithStopwords(NewStopwordFilter("english"))
+	if len(withStopwords.TokenFilters) != 2 || len(analyzer.TokenFilters) != 1 {
+		t.Fatalf("Expected the stopword filter to be added to a copy, got %v", withStopwords.TokenFilters)
+	}
+	if _, ok := withStopwords.TokenFilters[0].(*StopwordFilter); !ok {
+		t.Errorf("Expected the stopword filter before the stemmer, got %v", withStopwords.TokenFilters)
+	}
+	if filters := withStopwords.WithStopwords(nil).TokenFilters; len(filters) != 1 {
+		t.Errorf("Expected the stopword filter to be removed, got %v", filters)
+	}
+}
+
+func TestStopwordConfigFromEnv(t *testing.T) {
+	t.Setenv(ENV_STOPWORDS, "None")
+	t.Setenv(ENV_STOPWORDS_FILE, "/stopwords.txt")
+	t.Setenv(ENV_PHRASE_STOPWORDS, "1")
+
+	config := StopwordConfigFromEnv()
+	if !config.Disabled || config.File != "/stopwords.txt" || !config.KeepInPhrases {
+		t.Errorf("Unexpected configuration %+v", config)
+	}
+}
+
+func TestAnalyzer(t *testing.T) {
+	stripDigits := CharFilterFunc(func(text string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return -1
+			}
+			return r
+		}, text)
+	})
+	// a filter doubling each token at the same position, as a synonym filter would
+	double := TokenFilterFunc(func(tokens []Token) []Token {
+		doubled := make([]Token, 0, 2*len(tokens))
+		for _, token := range tokens {
+			doubled = append(doubled, token, Token{Term: token.Term + token.Term, Position: token.Position})
+		}
+		return doubled
+	})
+
+	analyzer := NewAnalyzer([]CharFilter{LowercaseFilter, stripDigits}, WhitespaceTokenizer{}, NewStopwordFilter("english"), double)
+	tokens := analyzer.Analyze("The Hobbit2 is a Book")
+	expected := []Token{{"hobbit", 1}, {"hobbithobbit", 1}, {"book", 4}, {"bookbook", 4}}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected %v, got %v", expected, tokens)
+	}
+
+	if normalized := analyzer.Normalize("HOBB1*"); normalized != "hobb*" {
+		t.Errorf("Expected the char filters to be applied, got %s", normalized)
+	}
+}
+
+func TestAnalyzeLanguageUnstemmed(t *testing.T) {
+	// the filters after the stemmer apply to the unstemmed tokens as well
+	upper := TokenFilterFunc(func(tokens []Token) []Token {
+		upper := make([]Token, len(tokens))
+		for i, token := range tokens {
+			upper[i] = Token{Term: strings.ToUpper(token.Term), Position: token.Position}
+		}
+		return upper
+	})
+	analyzer := NewAnalyzer([]CharFilter{LowercaseFilter}, WhitespaceTokenizer{}, NewStopwordFilter("english"), NewStemmerFilter("english"), upper)
+	if !analyzer.Stems() {
+		t.Error("Expected the analyzer to stem")
+	}
+
+	tokens, unstemmed := analyzer.AnalyzeLanguageUnstemmed("The hobbits love happiness", "en")
+	if expected := []Token{{"HOBBIT", 1}, {"LOVE", 2}, {"HAPPI", 3}}; !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected %v, got %v", expected, tokens)
+	}
+	if expected := []Token{{"THE", 0}, {"HOBBITS", 1}, {"LOVE", 2}, {"HAPPINESS", 3}}; !reflect.DeepEqual(unstemmed, expected) {
+		t.Errorf("Expected %v, got %v", expected, unstemmed)
+	}
+	if !reflect.DeepEqual(tokens, analyzer.AnalyzeLanguage("The hobbits love happiness", "en")) {
+		t.Error("Expected the same tokens as AnalyzeLanguage")
+	}
+
+	if !reflect.DeepEqual(unstemmed, analyzer.Unstemmed().AnalyzeLanguage("The hobbits love happiness", "en")) {
+		t.Error("Expected the same unstemmed tokens as the unstemmed analyzer")
+	}
+
+	if _, unstemmed := NewWhitespaceAnalyzer().AnalyzeLanguageUnstemmed("hobbits", "en"); unstemmed != nil || NewWhitespaceAnalyzer().Stems() {
+		t.Errorf("Expected no unstemmed tokens without a stemmer or a stopword filter, got %v", unstemmed)
+	}
+	// the stopwords are kept in the unstemmed tokens, the punctuation is not
+	analyzer = NewWhitespaceAnalyzer().WithStopwords(NewStopwordFilter("english"))
+	if _, unstemmed := analyzer.AnalyzeLanguageUnstemmed("the hobbit , of the shire", "en"); !reflect.DeepEqual(Terms(unstemmed), []string{"the", "hobbit", "of", "the", "shire"}) {
+		t.Errorf("Expected the stopwords in the unstemmed tokens, got %v", unstemmed)
+	}
+}
+
+func TestRemovesStopwords(t *testing.T) {
+	if !NewStandardAnalyzer().RemovesStopwords() {
+		t.Error("Expected the standard analyzer to remove the stopwords")
+	}
+	if NewStandardAnalyzer().WithStopwords(NewCustomStopwordFilter(nil)).RemovesStopwords() {
+		t.Error("Expected an empty custom list to keep the stopwords")
+	}
+	if !NewWhitespaceAnalyzer().WithStopwords(NewCustomStopwordFilter([]string{"the"})).RemovesStopwords() {
+		t.Error("Expected a custom list to remove the stopwords")
+	}
+	if NewWhitespaceAnalyzer().RemovesStopwords() {
+		t.Error("Expected an analyzer without stopword filter to keep the stopwords")
+	}
+}
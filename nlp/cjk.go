@@ -0,0 +1,68 @@
+package nlp
+
+import (
+	"unicode"
+)
+
+// CJKTokenizer splits the runs of Chinese and Japanese characters (Han, Hiragana and Katakana) of a text into
+// overlapping bigrams, e.g. 東京都 -> [東京, 京都], and the rest of the text with another tokenizer.
+//
+// CJK text has no spaces between its words, and indexing every pair of adjacent characters finds the words of
+// a query without segmenting the text into words first.
+type CJKTokenizer struct {
+	// Unigrams outputs every character as well, at the position of the bigram it starts,
+	// so that single character queries match too.
+	Unigrams bool
+	// Fallback tokenizes the text between the CJK runs, WhitespaceTokenizer if nil.
+	Fallback Tokenizer
+}
+
+/**
+ * Create a CJK bigram tokenizer.
+ *
+ * @param fallback The tokenizer of the text that is not Chinese or Japanese, WhitespaceTokenizer if nil
+ * @param unigrams Whether the single characters are output as well as the bigrams
+ * @return *CJKTokenizer
+ */
+func NewCJKTokenizer(fallback Tokenizer, unigrams bool) *CJKTokenizer {
+	return &CJKTokenizer{Unigrams: unigrams, Fallback: fallback}
+}
+
+func (t *CJKTokenizer) Tokenize(text string) []Token {
+	return tokenizeScript(text, isCJK, t.bigrams, t.Fallback)
+}
+
+// bigrams returns the bigrams of a run of CJK characters, a run of one character being output as a unigram.
+func (t *CJKTokenizer) bigrams(run []rune) []Token {
+	if len(run) == 1 {
+		return []Token{{Term: string(run), Position: 0}}
+	}
+
+	tokens := make([]Token, 0, 2*len(run))
+	for i := range run {
+		if t.Unigrams {
+			tokens = append(tokens, Token{Term: string(run[i]), Position: i})
+		}
+		if i+1 < len(run) {
+			tokens = append(tokens, Token{Term: string(run[i : i+2]), Position: i})
+		}
+	}
+	return tokens
+}
+
+// isCJK returns whether a rune is a Chinese or Japanese character.
+func isCJK(r rune) bool {
+	// the prolonged sound mark (e.g. in コーヒー) belongs to both scripts of Japanese
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) || r == 'ー'
+}
+
+/**
+ * Create an analyzer for Chinese and Japanese text: normalize the text (see NormalizationFilters),
+ * and split the CJK runs into bigrams.
+ *
+ * @param unigrams Whether the single characters are indexed as well as the bigrams
+ * @return *Analyzer
+ */
+func NewCJKAnalyzer(unigrams bool) *Analyzer {
+	return NewAnalyzer(NormalizationFilters(false), NewCJKTokenizer(nil, unigrams))
+}
@@ -0,0 +1,49 @@
+package nlp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCJKTokenizer(t *testing.T) {
+	tokens := NewCJKTokenizer(nil, false).Tokenize("東京都に住む Tokyo 人")
+	expected := []Token{{"東京", 0}, {"京都", 1}, {"都に", 2}, {"に住", 3}, {"住む", 4}, {"Tokyo", 5}, {"人", 6}}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected %v, got %v", expected, tokens)
+	}
+
+	tokens = NewCJKTokenizer(nil, true).Tokenize("北京大学")
+	expected = []Token{{"北", 0}, {"北京", 0}, {"京", 1}, {"京大", 1}, {"大", 2}, {"大学", 2}, {"学", 3}}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected %v, got %v", expected, tokens)
+	}
+
+	// katakana words keep their prolonged sound marks
+	if terms := Terms(NewCJKTokenizer(nil, false).Tokenize("コーヒー")); !reflect.DeepEqual(terms, []string{"コー", "ーヒ", "ヒー"}) {
+		t.Errorf("Expected the bigrams of コーヒー, got %v", terms)
+	}
+}
+
+func TestCJKAnalyzer(t *testing.T) {
+	analyzer := NewCJKAnalyzer(false)
+	document := Terms(analyzer.Analyze("我爱北京天安门"))
+	query := Terms(analyzer.Analyze("北京"))
+	if len(query) != 1 || !containsTerm(document, query[0]) {
+		t.Errorf("Expected the query %v to match the document %v", query, document)
+	}
+
+	// the Hangul words are split by the korean tokenizer, the CJK runs by the CJK tokenizer
+	tokenizer := NewKoreanTokenizer(NewCJKTokenizer(nil, false))
+	if terms := Terms(tokenizer.Tokenize("이지은(李知恩)은 가수")); !reflect.DeepEqual(terms, []string{"이지은", "(", "李知", "知恩", ")", "가수"}) {
+		t.Errorf("Unexpected terms %v", terms)
+	}
+}
+
+func containsTerm(terms []string, term string) bool {
+	for _, t := range terms {
+		if t == term {
+			return true
+		}
+	}
+	return false
+}
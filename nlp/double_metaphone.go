@@ -0,0 +1,549 @@
+package nlp
+
+import (
+	"strings"
+)
+
+// METAPHONE_LENGTH is the maximum length of the Double Metaphone codes.
+const METAPHONE_LENGTH = 4
+
+// the sequences of letters checked by several rules of Double Metaphone
+var (
+	metaphoneSilentStarts   = []string{"GN", "KN", "PN", "WR", "PS"}
+	metaphoneGermanicStarts = []string{"VAN ", "VON "}
+	metaphoneCHFollowers    = []string{"L", "R", "N", "M", "B", "H", "F", "V", "W", " "}
+	metaphoneGStarts        = []string{"ES", "EP", "EB", "EL", "EY", "IB", "IL", "IN", "IE", "EI", "ER"}
+	metaphoneJFollowers     = []string{"L", "T", "K", "S", "N", "M", "B", "Z"}
+)
+
+// metaphoneResult accumulates the primary and the alternate codes of a word, up to METAPHONE_LENGTH letters each.
+type metaphoneResult struct {
+	primary   strings.Builder
+	alternate strings.Builder
+}
+
+func (r *metaphoneResult) add(primary string, alternate string) {
+	r.addPrimary(primary)
+	r.addAlternate(alternate)
+}
+
+func (r *metaphoneResult) addPrimary(code string) {
+	r.primary.WriteString(code[:min(len(code), METAPHONE_LENGTH-r.primary.Len())])
+}
+
+func (r *metaphoneResult) addAlternate(code string) {
+	r.alternate.WriteString(code[:min(len(code), METAPHONE_LENGTH-r.alternate.Len())])
+}
+
+func (r *metaphoneResult) complete() bool {
+	return r.primary.Len() >= METAPHONE_LENGTH && r.alternate.Len() >= METAPHONE_LENGTH
+}
+
+// metaphoneWord is an uppercase word being encoded by Double Metaphone.
+type metaphoneWord struct {
+	value         string
+	slavoGermanic bool // whether the word looks Slavic or Germanic, which changes the pronunciation of some letters
+}
+
+// at returns the letter at an index of the word, 0 out of the word.
+func (w metaphoneWord) at(index int) byte {
+	if index < 0 || index >= len(w.value) {
+		return 0
+	}
+	return w.value[index]
+}
+
+// has returns whether the word has one of the sequences of letters at an index, false out of the word.
+func (w metaphoneWord) has(index int, sequences ...string) bool {
+	for _, sequence := range sequences {
+		if index >= 0 && index+len(sequence) <= len(w.value) && w.value[index:index+len(sequence)] == sequence {
+			return true
+		}
+	}
+	return false
+}
+
+// last is the index of the last letter of the word.
+func (w metaphoneWord) last() int {
+	return len(w.value) - 1
+}
+
+func isMetaphoneVowel(c byte) bool {
+	return strings.IndexByte("AEIOUY", c) >= 0
+}
+
+/**
+ * Encode a word with the Double Metaphone algorithm of Lawrence Philips, which gives the same codes to the words
+ * sounding alike in English, including the names of other origins (e.g. `Smith` and `Schmidt`).
+ * The letters other than A to Z are ignored, e.g. the diacritics must be stripped beforehand.
+ *
+ * @param word string
+ * @return (string, string) the primary and the alternate codes, of at most METAPHONE_LENGTH letters,
+ *         the same code twice if the word has a single pronunciation, and empty codes if it has no letter
+ */
+func DoubleMetaphone(word string) (string, string) {
+	var letters strings.Builder
+	for _, r := range strings.ToUpper(word) {
+		if (r >= 'A' && r <= 'Z') || r == ' ' {
+			letters.WriteRune(r)
+		}
+	}
+	value := strings.TrimSpace(letters.String())
+	if value == "" {
+		return "", ""
+	}
+	w := metaphoneWord{
+		value:         value,
+		slavoGermanic: strings.ContainsAny(value, "WK") || strings.Contains(value, "CZ") || strings.Contains(value, "WITZ"),
+	}
+
+	result := &metaphoneResult{}
+	index := 0
+	if w.has(0, metaphoneSilentStarts...) {
+		index = 1
+	}
+	for !result.complete() && index <= w.last() {
+		switch c := w.at(index); c {
+		case 'A', 'E', 'I', 'O', 'U', 'Y':
+			// the vowels are only kept at the start of the word
+			if index == 0 {
+				result.add("A", "A")
+			}
+			index++
+		case 'B':
+			result.add("P", "P")
+			index = w.skipDouble(index, "B")
+		case 'C':
+			index = w.encodeC(result, index)
+		case 'D':
+			index = w.encodeD(result, index)
+		case 'F':
+			result.add("F", "F")
+			index = w.skipDouble(index, "F")
+		case 'G':
+			index = w.encodeG(result, index)
+		case 'H':
+			// an H is only pronounced between vowels, or at the start of the word before a vowel
+			if (index == 0 || isMetaphoneVowel(w.at(index-1))) && isMetaphoneVowel(w.at(index+1)) {
+				result.add("H", "H")
+				index += 2
+			} else {
+				index++
+			}
+		case 'J':
+			index = w.encodeJ(result, index)
+		case 'K':
+			result.add("K", "K")
+			index = w.skipDouble(index, "K")
+		case 'L':
+			index = w.encodeL(result, index)
+		case 'M':
+			result.add("M", "M")
+			// the B of `dumb` and `thumb` is silent
+			if w.at(index+1) == 'M' || (w.has(index-1, "UMB") && (index+1 == w.last() || w.has(index+2, "ER"))) {
+				index += 2
+			} else {
+				index++
+			}
+		case 'N':
+			result.add("N", "N")
+			index = w.skipDouble(index, "N")
+		case 'P':
+			if w.at(index+1) == 'H' {
+				result.add("F", "F")
+				index += 2
+			} else {
+				result.add("P", "P")
+				index = w.skipDouble(index, "P", "B")
+			}
+		case 'Q':
+			result.add("K", "K")
+			index = w.skipDouble(index, "Q")
+		case 'R':
+			// the final R of the French words, e.g. `Rogier`
+			if index == w.last() && !w.slavoGermanic && w.has(index-2, "IE") && !w.has(index-4, "ME", "MA") {
+				result.addAlternate("R")
+			} else {
+				result.add("R", "R")
+			}
+			index = w.skipDouble(index, "R")
+		case 'S':
+			index = w.encodeS(result, index)
+		case 'T':
+			index = w.encodeT(result, index)
+		case 'V':
+			result.add("F", "F")
+			index = w.skipDouble(index, "V")
+		case 'W':
+			index = w.encodeW(result, index)
+		case 'X':
+			index = w.encodeX(result, index)
+		case 'Z':
+			index = w.encodeZ(result, index)
+		default:
+			index++
+		}
+	}
+	return result.primary.String(), result.alternate.String()
+}
+
+// skipDouble returns the index after a letter, skipping the next one if it is one of the given letters.
+func (w metaphoneWord) skipDouble(index int, next ...string) int {
+	if w.has(index+1, next...) {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (w metaphoneWord) encodeC(result *metaphoneResult, index int) int {
+	switch {
+	case w.isGermanicCH(index):
+		// e.g. `bacher`, `macher`
+		result.add("K", "K")
+		return index + 2
+	case index == 0 && w.has(index, "CAESAR"):
+		result.add("S", "S")
+		return index + 2
+	case w.has(index, "CH"):
+		return w.encodeCH(result, index)
+	case w.has(index, "CZ") && !w.has(index-2, "WICZ"):
+		// e.g. `czerny`
+		result.add("S", "X")
+		return index + 2
+	case w.has(index+1, "CIA"):
+		// e.g. `focaccia`
+		result.add("X", "X")
+		return index + 3
+	case w.has(index, "CC") && !(index == 1 && w.at(0) == 'M'):
+		// e.g. `bellocchio` but not `bacchus`
+		if w.has(index+2, "I", "E", "H") && !w.has(index+2, "HU") {
+			if (index == 1 && w.at(index-1) == 'A') || w.has(index-1, "UCCEE", "UCCES") {
+				// e.g. `accident`, `succeed`
+				result.add("KS", "KS")
+			} else {
+				result.add("X", "X")
+			}
+			return index + 3
+		}
+		result.add("K", "K")
+		return index + 2
+	case w.has(index, "CK", "CG", "CQ"):
+		result.add("K", "K")
+		return index + 2
+	case w.has(index, "CI", "CE", "CY"):
+		// the Italian and the English pronunciations
+		if w.has(index, "CIO", "CIE", "CIA") {
+			result.add("S", "X")
+		} else {
+			result.add("S", "S")
+		}
+		return index + 2
+	}
+
+	result.add("K", "K")
+	switch {
+	case w.has(index+1, " C", " Q", " G"):
+		// e.g. `mac caffrey`, `mac gregor`
+		return index + 3
+	case w.has(index+1, "C", "K", "Q") && !w.has(index+1, "CE", "CI"):
+		return index + 2
+	}
+	return index + 1
+}
+
+// isGermanicCH returns whether the C at an index is the CH pronounced K of the Germanic words, e.g. `bacher`.
+func (w metaphoneWord) isGermanicCH(index int) bool {
+	if w.has(index, "CHIA") {
+		return true
+	}
+	if index <= 1 || isMetaphoneVowel(w.at(index-2)) || !w.has(index-1, "ACH") {
+		return false
+	}
+	next := w.at(index + 2)
+	return (next != 'I' && next != 'E') || w.has(index-2, "BACHER", "MACHER")
+}
+
+func (w metaphoneWord) encodeCH(result *metaphoneResult, index int) int {
+	switch {
+	case index > 0 && w.has(index, "CHAE"):
+		// e.g. `michael`
+		result.add("K", "X")
+	case index == 0 && (w.has(index+1, "HARAC", "HARIS") || w.has(index+1, "HOR", "HYM", "HIA", "HEM")) && !w.has(0, "CHORE"):
+		// the Greek roots, e.g. `chemistry`, `chorus`
+		result.add("K", "K")
+	case w.has(0, metaphoneGermanicStarts...) || w.has(0, "SCH") ||
+		w.has(index-2, "ORCHES", "ARCHIT", "ORCHID") || w.has(index+2, "T", "S") ||
+		((w.has(index-1, "A", "O", "U", "E") || index == 0) && (w.has(index+2, metaphoneCHFollowers...) || index+1 == w.last())):
+		// the Germanic words, and the CH followed by a consonant, e.g. `wachtler`, `school`
+		result.add("K", "K")
+	case index > 0 && w.has(0, "MC"):
+		// e.g. `mchugh`
+		result.add("K", "K")
+	case index > 0:
+		result.add("X", "K")
+	default:
+		result.add("X", "X")
+	}
+	return index + 2
+}
+
+func (w metaphoneWord) encodeD(result *metaphoneResult, index int) int {
+	switch {
+	case w.has(index, "DG"):
+		if w.has(index+2, "I", "E", "Y") {
+			// e.g. `edge`
+			result.add("J", "J")
+			return index + 3
+		}
+		// e.g. `edgar`
+		result.add("TK", "TK")
+		return index + 2
+	case w.has(index, "DT", "DD"):
+		result.add("T", "T")
+		return index + 2
+	}
+	result.add("T", "T")
+	return index + 1
+}
+
+func (w metaphoneWord) encodeG(result *metaphoneResult, index int) int {
+	switch {
+	case w.at(index+1) == 'H':
+		return w.encodeGH(result, index)
+	case w.at(index+1) == 'N':
+		switch {
+		case index == 1 && isMetaphoneVowel(w.at(0)) && !w.slavoGermanic:
+			result.add("KN", "N")
+		case !w.has(index+2, "EY") && w.at(index+1) != 'Y' && !w.slavoGermanic:
+			// not e.g. `cagney`
+			result.add("N", "KN")
+		default:
+			result.add("KN", "KN")
+		}
+		return index + 2
+	case w.has(index+1, "LI") && !w.slavoGermanic:
+		// e.g. `tagliaro`
+		result.add("KL", "L")
+		return index + 2
+	case index == 0 && (w.at(index+1) == 'Y' || w.has(index+1, metaphoneGStarts...)):
+		// e.g. `gerben`, `gyles`
+		result.add("K", "J")
+		return index + 2
+	case (w.has(index+1, "ER") || w.at(index+1) == 'Y') && !w.has(0, "DANGER", "RANGER", "MANGER") &&
+		!w.has(index-1, "E", "I") && !w.has(index-1, "RGY", "OGY"):
+		// e.g. `berger`, `biaggi`
+		result.add("K", "J")
+		return index + 2
+	case w.has(index+1, "E", "I", "Y") || w.has(index-1, "AGGI", "OGGI"):
+		switch {
+		case w.has(0, metaphoneGermanicStarts...) || w.has(0, "SCH") || w.has(index+1, "ET"):
+			// the Germanic words
+			result.add("K", "K")
+		case w.has(index+1, "IER"):
+			result.add("J", "J")
+		default:
+			result.add("J", "K")
+		}
+		return index + 2
+	}
+	result.add("K", "K")
+	return w.skipDouble(index, "G")
+}
+
+func (w metaphoneWord) encodeGH(result *metaphoneResult, index int) int {
+	switch {
+	case index > 0 && !isMetaphoneVowel(w.at(index-1)):
+		result.add("K", "K")
+	case index == 0:
+		// e.g. `ghislane`, `ghiradelli`
+		if w.at(index+2) == 'I' {
+			result.add("J", "J")
+		} else {
+			result.add("K", "K")
+		}
+	case (index > 1 && w.has(index-2, "B", "H", "D")) || (index > 2 && w.has(index-3, "B", "H", "D")) ||
+		(index > 3 && w.has(index-4, "B", "H")):
+		// the silent GH, e.g. `hugh`, `bough`, `broughton`
+	case index > 2 && w.at(index-1) == 'U' && w.has(index-3, "C", "G", "L", "R", "T"):
+		// e.g. `laugh`, `cough`, `rough`, `tough`
+		result.add("F", "F")
+	case index > 0 && w.at(index-1) != 'I':
+		result.add("K", "K")
+	}
+	return index + 2
+}
+
+func (w metaphoneWord) encodeJ(result *metaphoneResult, index int) int {
+	if w.has(index, "JOSE") || w.has(0, "SAN ") {
+		// the Spanish pronunciation, e.g. `jose`, `san jacinto`
+		if (index == 0 && w.at(index+4) == ' ') || len(w.value) == 4 || w.has(0, "SAN ") {
+			result.add("H", "H")
+		} else {
+			result.add("J", "H")
+		}
+		return index + 1
+	}
+
+	switch {
+	case index == 0:
+		// e.g. `yankelovich` and `jankelowicz`
+		result.add("J", "A")
+	case isMetaphoneVowel(w.at(index-1)) && !w.slavoGermanic && (w.at(index+1) == 'A' || w.at(index+1) == 'O'):
+		// the Spanish pronunciation, e.g. `bajador`
+		result.add("J", "H")
+	case index == w.last():
+		result.addPrimary("J")
+	case !w.has(index+1, metaphoneJFollowers...) && !w.has(index-1, "S", "K", "L"):
+		result.add("J", "J")
+	}
+	return w.skipDouble(index, "J")
+}
+
+func (w metaphoneWord) encodeL(result *metaphoneResult, index int) int {
+	if w.at(index+1) != 'L' {
+		result.add("L", "L")
+		return index + 1
+	}
+	// the Spanish LL, e.g. `cabrillo`, `gallegos`
+	if (index == len(w.value)-3 && w.has(index-1, "ILLO", "ILLA", "ALLE")) ||
+		((w.has(len(w.value)-2, "AS", "OS") || w.has(w.last(), "A", "O")) && w.has(index-1, "ALLE")) {
+		result.addPrimary("L")
+	} else {
+		result.add("L", "L")
+	}
+	return index + 2
+}
+
+func (w metaphoneWord) encodeS(result *metaphoneResult, index int) int {
+	switch {
+	case w.has(index-1, "ISL", "YSL"):
+		// the silent S, e.g. `island`, `carlysle`
+		return index + 1
+	case index == 0 && w.has(index, "SUGAR"):
+		result.add("X", "S")
+		return index + 1
+	case w.has(index, "SH"):
+		// the Germanic words, e.g. `holmes`, `rheinsheim`
+		if w.has(index+1, "HEIM", "HOEK", "HOLM", "HOLZ") {
+			result.add("S", "S")
+		} else {
+			result.add("X", "X")
+		}
+		return index + 2
+	case w.has(index, "SIO", "SIA") || w.has(index, "SIAN"):
+		// the Italian and the Armenian words
+		if w.slavoGermanic {
+			result.add("S", "S")
+		} else {
+			result.add("S", "X")
+		}
+		return index + 3
+	case (index == 0 && w.has(index+1, "M", "N", "L", "W")) || w.has(index+1, "Z"):
+		// the German and the anglicized pronunciations, e.g. `smith` and `schmidt`, `snider` and `schneider`
+		result.add("S", "X")
+		return w.skipDouble(index, "Z")
+	case w.has(index, "SC"):
+		return w.encodeSC(result, index)
+	}
+
+	// the silent final S of the French words, e.g. `resnais`, `artois`
+	if index == w.last() && w.has(index-2, "AI", "OI") {
+		result.addAlternate("S")
+	} else {
+		result.add("S", "S")
+	}
+	return w.skipDouble(index, "S", "Z")
+}
+
+func (w metaphoneWord) encodeSC(result *metaphoneResult, index int) int {
+	switch {
+	case w.at(index+2) == 'H':
+		switch {
+		case w.has(index+3, "ER", "EN"):
+			// e.g. `schenker`
+			result.add("X", "SK")
+		case w.has(index+3, "OO", "UY", "ED", "EM"):
+			// the Dutch words, e.g. `school`, `schooner`
+			result.add("SK", "SK")
+		case index == 0 && !isMetaphoneVowel(w.at(3)) && w.at(3) != 'W':
+			// e.g. `schlesinger`
+			result.add("X", "S")
+		default:
+			result.add("X", "X")
+		}
+	case w.has(index+2, "I", "E", "Y"):
+		result.add("S", "S")
+	default:
+		result.add("SK", "SK")
+	}
+	return index + 3
+}
+
+func (w metaphoneWord) encodeT(result *metaphoneResult, index int) int {
+	switch {
+	case w.has(index, "TION") || w.has(index, "TIA", "TCH"):
+		result.add("X", "X")
+		return index + 3
+	case w.has(index, "TH") || w.has(index, "TTH"):
+		// e.g. `thomas`, `thames`, and the Germanic words
+		if w.has(index+2, "OM", "AM") || w.has(0, metaphoneGermanicStarts...) || w.has(0, "SCH") {
+			result.add("T", "T")
+		} else {
+			result.add("0", "T")
+		}
+		return index + 2
+	}
+	result.add("T", "T")
+	return w.skipDouble(index, "T", "D")
+}
+
+func (w metaphoneWord) encodeW(result *metaphoneResult, index int) int {
+	switch {
+	case w.has(index, "WR"):
+		result.add("R", "R")
+		return index + 2
+	case index == 0 && (isMetaphoneVowel(w.at(index+1)) || w.has(index, "WH")):
+		// e.g. `wasserman` and `vasserman`
+		if isMetaphoneVowel(w.at(index + 1)) {
+			result.add("A", "F")
+		} else {
+			result.add("A", "A")
+		}
+		return index + 1
+	case (index == w.last() && isMetaphoneVowel(w.at(index-1))) ||
+		w.has(index-1, "EWSKI", "EWSKY", "OWSKI", "OWSKY") || w.has(0, "SCH"):
+		// the Polish words, e.g. `filipowicz`
+		result.addAlternate("F")
+		return index + 1
+	case w.has(index, "WICZ", "WITZ"):
+		result.add("TS", "FX")
+		return index + 4
+	}
+	return index + 1
+}
+
+func (w metaphoneWord) encodeX(result *metaphoneResult, index int) int {
+	if index == 0 {
+		result.add("S", "S")
+		return index + 1
+	}
+	// the silent final X of the French words, e.g. `breaux`
+	if !(index == w.last() && (w.has(index-3, "IAU", "EAU") || w.has(index-2, "AU", "OU"))) {
+		result.add("KS", "KS")
+	}
+	return w.skipDouble(index, "C", "X")
+}
+
+func (w metaphoneWord) encodeZ(result *metaphoneResult, index int) int {
+	if w.at(index+1) == 'H' {
+		// the Chinese pinyin, e.g. `zhao`
+		result.add("J", "J")
+		return index + 2
+	}
+	if w.has(index+1, "ZO", "ZI", "ZA") || (w.slavoGermanic && index > 0 && w.at(index-1) != 'T') {
+		result.add("S", "TS")
+	} else {
+		result.add("S", "S")
+	}
+	return w.skipDouble(index, "Z")
+}
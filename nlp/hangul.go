@@ -0,0 +1,219 @@
+package nlp
+
+import (
+	"strings"
+)
+
+const (
+	// the range of the precomposed Hangul syllables, 가 to 힣
+	HANGUL_SYLLABLE_FIRST = 0xAC00
+	HANGUL_SYLLABLE_LAST  = 0xD7A3
+
+	// the first conjoining jamo of each kind, into which NFKC turns the compatibility jamo (e.g. ㄱ into U+1100)
+	conjoiningChoseongFirst  = 0x1100
+	conjoiningJungseongFirst = 0x1161
+	conjoiningJongseongFirst = 0x11A8
+)
+
+// the compatibility jamo of the initial consonants (choseong), the vowels (jungseong) and the final consonants
+// (jongseong), in the order of the composition of the syllables. A syllable without final consonant has the index 0.
+var (
+	choseongJamo  = []rune("ㄱㄲㄴㄷㄸㄹㅁㅂㅃㅅㅆㅇㅈㅉㅊㅋㅌㅍㅎ")
+	jungseongJamo = []rune("ㅏㅐㅑㅒㅓㅔㅕㅖㅗㅘㅙㅚㅛㅜㅝㅞㅟㅠㅡㅢㅣ")
+	jongseongJamo = []rune(" ㄱㄲㄳㄴㄵㄶㄷㄹㄺㄻㄼㄽㄾㄿㅀㅁㅂㅄㅅㅆㅇㅈㅊㅋㅌㅍㅎ")
+
+	choseongIndex  = jamoIndex(choseongJamo)
+	jungseongIndex = jamoIndex(jungseongJamo)
+	jongseongIndex = jamoIndex(jongseongJamo)
+)
+
+// compoundJamo are the keystrokes of the compound vowels and final consonants, which are typed as two jamo on
+// a Korean keyboard, e.g. ㅘ as ㅗ then ㅏ, and ㄺ as ㄹ then ㄱ. The double consonants (e.g. ㄲ) are a single keystroke.
+var compoundJamo = map[rune]string{
+	'ㅘ': "ㅗㅏ", 'ㅙ': "ㅗㅐ", 'ㅚ': "ㅗㅣ", 'ㅝ': "ㅜㅓ", 'ㅞ': "ㅜㅔ", 'ㅟ': "ㅜㅣ", 'ㅢ': "ㅡㅣ",
+	'ㄳ': "ㄱㅅ", 'ㄵ': "ㄴㅈ", 'ㄶ': "ㄴㅎ", 'ㄺ': "ㄹㄱ", 'ㄻ': "ㄹㅁ", 'ㄼ': "ㄹㅂ", 'ㄽ': "ㄹㅅ", 'ㄾ': "ㄹㅌ",
+	'ㄿ': "ㄹㅍ", 'ㅀ': "ㄹㅎ", 'ㅄ': "ㅂㅅ",
+}
+
+// composedJamo is the compound jamo of each pair of keystrokes, the inverse of compoundJamo.
+var composedJamo = func() map[string]rune {
+	composed := make(map[string]rune, len(compoundJamo))
+	for jamo, keystrokes := range compoundJamo {
+		composed[keystrokes] = jamo
+	}
+	return composed
+}()
+
+// writeKeystrokes writes a jamo, split into its keystrokes if it is compound.
+func writeKeystrokes(b *strings.Builder, jamo rune) {
+	if keystrokes, ok := compoundJamo[jamo]; ok {
+		b.WriteString(keystrokes)
+		return
+	}
+	b.WriteRune(jamo)
+}
+
+func jamoIndex(jamo []rune) map[rune]int {
+	index := make(map[rune]int, len(jamo))
+	for i, r := range jamo {
+		if r != ' ' {
+			index[r] = i
+		}
+	}
+	return index
+}
+
+// compatibilityJamo returns the compatibility jamo of a conjoining jamo (e.g. U+1100 for ㄱ), the rune itself otherwise.
+func compatibilityJamo(r rune) rune {
+	switch {
+	case r >= conjoiningChoseongFirst && r < conjoiningChoseongFirst+rune(len(choseongJamo)):
+		return choseongJamo[r-conjoiningChoseongFirst]
+	case r >= conjoiningJungseongFirst && r < conjoiningJungseongFirst+rune(len(jungseongJamo)):
+		return jungseongJamo[r-conjoiningJungseongFirst]
+	case r >= conjoiningJongseongFirst && r < conjoiningJongseongFirst+rune(len(jongseongJamo)-1):
+		return jongseongJamo[r-conjoiningJongseongFirst+1]
+	}
+	return r
+}
+
+// isHangulJamo returns whether a rune is a compatibility or a conjoining jamo.
+func isHangulJamo(r rune) bool {
+	r = compatibilityJamo(r)
+	_, consonant := choseongIndex[r]
+	_, vowel := jungseongIndex[r]
+	_, final := jongseongIndex[r]
+	return consonant || vowel || final
+}
+
+// ContainsHangul returns whether a text contains a Hangul syllable or jamo.
+func ContainsHangul(text string) bool {
+	return strings.IndexFunc(text, func(r rune) bool {
+		return isHangulSyllable(r) || isHangulJamo(r)
+	}) >= 0
+}
+
+/**
+ * Decompose the Hangul syllables of a text into their jamo as they are typed, e.g. `아이유` into `ㅇㅏㅇㅣㅇㅠ` and
+ * `닭` into `ㄷㅏㄹㄱ`, so that the texts differing by a single keystroke are a single edit apart, and so that the
+ * syllables being typed are prefixes of the syllables they become (e.g. `달` of `닭`, `고` of `과`).
+ * The compound vowels and final consonants are split (see compoundJamo), the conjoining jamo are turned into
+ * compatibility jamo, the rest of the text is left as it is. ComposeHangul turns the jamo back into syllables.
+ *
+ * @param text string
+ * @return string the text with the compatibility jamo of its syllables
+ */
+func DecomposeHangul(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if !isHangulSyllable(r) {
+			writeKeystrokes(&b, compatibilityJamo(r))
+			continue
+		}
+		offset := int(r - HANGUL_SYLLABLE_FIRST)
+		b.WriteRune(choseongJamo[offset/(len(jungseongJamo)*len(jongseongJamo))])
+		writeKeystrokes(&b, jungseongJamo[offset/len(jongseongJamo)%len(jungseongJamo)])
+		if final := offset % len(jongseongJamo); final > 0 {
+			writeKeystrokes(&b, jongseongJamo[final])
+		}
+	}
+	return b.String()
+}
+
+/**
+ * Compose the jamo of a text into Hangul syllables, as they are typed on a Korean keyboard: a consonant followed by
+ * a vowel starts a syllable, two vowels or two final consonants forming a compound jamo are combined (e.g. ㅗㅏ into
+ * ㅘ, ㄹㄱ into ㄺ), and a consonant following a syllable is its final consonant unless a vowel follows it,
+ * e.g. `ㅇㅏㄴㄴㅕㅇ` gives `안녕` and `ㄷㅏㄹㄱㅣ` gives `달기`. The jamo which cannot be composed are left as they are.
+ * It is the inverse of DecomposeHangul for the texts made of syllables.
+ *
+ * @param text string
+ * @return string the text with its jamo composed into syllables
+ */
+func ComposeHangul(text string) string {
+	runes := []rune(text)
+	var b strings.Builder
+	initial, vowel, final := -1, -1, 0
+
+	flush := func() {
+		if initial >= 0 && vowel >= 0 {
+			b.WriteRune(composeSyllable(initial, vowel, final))
+		} else if initial >= 0 {
+			b.WriteRune(choseongJamo[initial])
+		}
+		initial, vowel, final = -1, -1, 0
+	}
+	for i, r := range runes {
+		r = compatibilityJamo(r)
+		if index, ok := jungseongIndex[r]; ok && initial >= 0 && final == 0 {
+			if vowel < 0 {
+				vowel = index
+				continue
+			}
+			if compound, ok := composedJamo[string([]rune{jungseongJamo[vowel], r})]; ok {
+				vowel = jungseongIndex[compound]
+				continue
+			}
+		}
+
+		nextIsVowel := false
+		if i+1 < len(runes) {
+			_, nextIsVowel = jungseongIndex[compatibilityJamo(runes[i+1])]
+		}
+		if initial >= 0 && vowel >= 0 && !nextIsVowel {
+			if index, ok := jongseongIndex[r]; ok && final == 0 {
+				final = index
+				continue
+			}
+			if compound, ok := composedJamo[string([]rune{jongseongJamo[final], r})]; ok && final > 0 {
+				final = jongseongIndex[compound]
+				continue
+			}
+		}
+
+		flush()
+		if index, ok := choseongIndex[r]; ok {
+			initial = index
+			continue
+		}
+		b.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
+
+func composeSyllable(initial int, vowel int, final int) rune {
+	return rune(HANGUL_SYLLABLE_FIRST + (initial*len(jungseongJamo)+vowel)*len(jongseongJamo) + final)
+}
+
+/**
+ * Return the initial consonants (chosung) of the Hangul syllables of a text, e.g. `ㅇㅇㅇ` for `아이유`,
+ * which are commonly typed to search for a Korean word. The conjoining initial consonants are turned into
+ * compatibility jamo, the rest of the text is left as it is.
+ *
+ * @param text string
+ * @return string
+ */
+func Chosung(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if isHangulSyllable(r) {
+			b.WriteRune(choseongJamo[int(r-HANGUL_SYLLABLE_FIRST)/(len(jungseongJamo)*len(jongseongJamo))])
+			continue
+		}
+		b.WriteRune(compatibilityJamo(r))
+	}
+	return b.String()
+}
+
+// IsChosung returns whether a text is made of initial consonants only, e.g. `ㅇㅇㅇ`.
+func IsChosung(text string) bool {
+	if text == "" {
+		return false
+	}
+	for _, r := range text {
+		if _, ok := choseongIndex[compatibilityJamo(r)]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package nlp
+
+import (
+	"testing"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestDecomposeHangul(t *testing.T) {
+	cases := map[string]string{
+		"아이유":     "ㅇㅏㅇㅣㅇㅠ",
+		"안녕하세요":   "ㅇㅏㄴㄴㅕㅇㅎㅏㅅㅔㅇㅛ",
+		"닭":       "ㄷㅏㄹㄱ",
+		"과자":      "ㄱㅗㅏㅈㅏ",
+		"IU 아이유!": "IU ㅇㅏㅇㅣㅇㅠ!",
+		"hobbit":  "hobbit",
+		"ㅋㅋ":      "ㅋㅋ",
+		"":        "",
+		"읽어요 괜찮아": "ㅇㅣㄹㄱㅇㅓㅇㅛ ㄱㅗㅐㄴㅊㅏㄴㅎㅇㅏ",
+		"값의":      "ㄱㅏㅂㅅㅇㅡㅣ",
+	}
+	for text, expected := range cases {
+		decomposed := DecomposeHangul(text)
+		if decomposed != expected {
+			t.Errorf("%s: expected %q, got %q", text, expected, decomposed)
+		}
+		// the decomposition is reversible
+		if composed := ComposeHangul(decomposed); composed != text {
+			t.Errorf("%s: expected the composition to give it back, got %q", text, composed)
+		}
+	}
+
+	// the conjoining jamo of the NFKC normalization are turned into compatibility jamo
+	if decomposed := DecomposeHangul(norm.NFKC.String("아ㅇ")); decomposed != "ㅇㅏㅇ" {
+		t.Errorf("Expected ㅇㅏㅇ, got %q", decomposed)
+	}
+	// the compound jamo are split into their keystrokes, the double consonants are a single one
+	if decomposed := DecomposeHangul("ㄳㅘㄲ"); decomposed != "ㄱㅅㅗㅏㄲ" {
+		t.Errorf("Expected ㄱㅅㅗㅏㄲ, got %q", decomposed)
+	}
+}
+
+func TestComposeHangul(t *testing.T) {
+	cases := map[string]string{
+		// a final consonant followed by a vowel starts the next syllable
+		"ㅇㅏㄴㅇㅕㅇ": "안영",
+		"ㅇㅏㄴㅕㅇ":  "아녕",
+		// the consonants which cannot be final start a syllable
+		"ㄱㅏㄸㅏ": "가따",
+		// the compound vowels and final consonants are combined, unless a vowel follows the second consonant
+		"ㄱㅗㅏ":   "과",
+		"ㄷㅏㄹㄱ":  "닭",
+		"ㄷㅏㄹㄱㅣ": "달기",
+		"ㄷㅏㄹㅋ":  "달ㅋ",
+		// the jamo which cannot be composed are kept
+		"ㅏㄱ":   "ㅏㄱ",
+		"ㅇㅏㅏ":  "아ㅏ",
+		"ㄳ":    "ㄳ",
+		"ㄱㅏ ㅂ": "가 ㅂ",
+	}
+	for jamo, expected := range cases {
+		if composed := ComposeHangul(jamo); composed != expected {
+			t.Errorf("%s: expected %q, got %q", jamo, expected, composed)
+		}
+	}
+}
+
+func TestChosung(t *testing.T) {
+	if chosung := Chosung("아이유 IU 까치"); chosung != "ㅇㅇㅇ IU ㄲㅊ" {
+		t.Errorf("Expected ㅇㅇㅇ IU ㄲㅊ, got %q", chosung)
+	}
+	cases := map[string]bool{
+		"ㅇㅇㅇ":                   true,
+		norm.NFKC.String("ㅇㅇㅇ"): true,
+		"ㄲㅊ":                    true,
+		"아이유":                   false,
+		"ㅇㅏ":                    false,
+		"ㄳ":                     false,
+		"":                      false,
+	}
+	for text, expected := range cases {
+		if IsChosung(text) != expected {
+			t.Errorf("%q: expected IsChosung to be %v", text, expected)
+		}
+	}
+	if !ContainsHangul("IU 아이유") || !ContainsHangul("ㅋㅋ") || ContainsHangul("hobbit") {
+		t.Errorf("Expected ContainsHangul to find the syllables and the jamo only")
+	}
+}
@@ -0,0 +1,231 @@
+package nlp
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// koreanLexiconData is a small lexicon of common nouns, particles (josa) and endings (eomi), in the CSV format of
+// mecab-ko-dic: surface, left id, right id, cost, part of speech tag, ... Only the surface and the tag are used.
+//
+//go:embed lexicon/korean.csv
+var koreanLexiconData string
+
+var (
+	defaultKoreanLexicon     *KoreanLexicon
+	defaultKoreanLexiconOnce sync.Once
+)
+
+// MIN_UNKNOWN_NOUN_LENGTH is the minimum number of syllables of a word that is not in the lexicon
+// for a particle or an ending to be stripped from it.
+const MIN_UNKNOWN_NOUN_LENGTH = 2
+
+var ErrInvalidLexicon = errors.New("invalid korean lexicon")
+
+// KoreanLexicon holds the morphemes used to split Korean words.
+type KoreanLexicon struct {
+	content    map[string]bool // nouns, verb and adjective stems, adverbs...
+	functional map[string]bool // particles, endings, copula and suffixes, which are not indexed
+	maxLength  int             // the length in runes of the longest morpheme
+}
+
+func NewKoreanLexicon() *KoreanLexicon {
+	return &KoreanLexicon{content: make(map[string]bool), functional: make(map[string]bool)}
+}
+
+/**
+ * Return the lexicon bundled with go4search.
+ *
+ * @return *KoreanLexicon
+ */
+func DefaultKoreanLexicon() *KoreanLexicon {
+	defaultKoreanLexiconOnce.Do(func() {
+		lexicon, err := LoadKoreanLexicon(strings.NewReader(koreanLexiconData))
+		if err != nil {
+			panic(err)
+		}
+		defaultKoreanLexicon = lexicon
+	})
+	return defaultKoreanLexicon
+}
+
+/**
+ * Load a lexicon in the CSV format of mecab-ko-dic (e.g. NNG.csv, JKS.csv, ...).
+ * Several files can be loaded into the same lexicon with AddLexicon.
+ *
+ * @param r The CSV content
+ * @return (*KoreanLexicon, error) the lexicon, ErrInvalidLexicon if a line has no part of speech tag
+ */
+func LoadKoreanLexicon(r io.Reader) (*KoreanLexicon, error) {
+	lexicon := NewKoreanLexicon()
+	if err := lexicon.AddLexicon(r); err != nil {
+		return nil, err
+	}
+	return lexicon, nil
+}
+
+/**
+ * Add the morphemes of a lexicon in the CSV format of mecab-ko-dic.
+ *
+ * @param r The CSV content
+ * @return error ErrInvalidLexicon if a line has no part of speech tag
+ */
+func (l *KoreanLexicon) AddLexicon(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidLexicon, err)
+		}
+		if len(record) < 5 {
+			line, _ := reader.FieldPos(0)
+			return fmt.Errorf("%w: line %d has no part of speech tag", ErrInvalidLexicon, line)
+		}
+		l.Add(record[0], record[4])
+	}
+}
+
+/**
+ * Add a morpheme to the lexicon.
+ *
+ * @param surface The morpheme as written, e.g. 아이유
+ * @param tag The part of speech tag of mecab-ko-dic (Sejong tag set), e.g. NNP, JX or XSV+EF
+ */
+func (l *KoreanLexicon) Add(surface string, tag string) {
+	if surface == "" {
+		return
+	}
+	if isFunctionalTag(tag) {
+		l.functional[surface] = true
+	} else {
+		l.content[surface] = true
+	}
+	l.maxLength = max(l.maxLength, utf8.RuneCountInString(surface))
+}
+
+// isFunctionalTag returns whether a part of speech tag is a particle (J*), an ending (E*), a suffix (XS*) or the copula.
+func isFunctionalTag(tag string) bool {
+	first, _, _ := strings.Cut(tag, "+")
+	return strings.HasPrefix(first, "J") || strings.HasPrefix(first, "E") || strings.HasPrefix(first, "XS") || first == "VCP"
+}
+
+/**
+ * Split a Korean word (eojeol) into its content morphemes, dropping the particles and the endings.
+ * e.g. 아이유는 -> [아이유], 대한민국의 -> [대한민국], 사용한다 -> [사용]
+ *
+ * The split with the fewest morphemes made of known morphemes is chosen. If there is none, the longest
+ * sequence of known particles and endings is stripped from the word, as long as MIN_UNKNOWN_NOUN_LENGTH
+ * syllables are left, so that unknown nouns (e.g. names) are handled too.
+ *
+ * @param word A word made of Hangul syllables
+ * @return []string the content morphemes, empty if the word is a particle or an ending
+ */
+func (l *KoreanLexicon) Split(word string) []string {
+	runes := []rune(word)
+	n := len(runes)
+	const impossible = -1
+
+	// tail[i] is the minimum number of functional morphemes making runes[i:], or impossible
+	tail := make([]int, n+1)
+	for i := n - 1; i >= 0; i-- {
+		tail[i] = impossible
+		for j := i + 1; j <= min(n, i+l.maxLength); j++ {
+			if tail[j] != impossible && l.functional[string(runes[i:j])] && (tail[i] == impossible || tail[j]+1 < tail[i]) {
+				tail[i] = tail[j] + 1
+			}
+		}
+	}
+
+	// head[j] is the minimum number of content morphemes making runes[:j], or impossible, and from[j] the start
+	// of the last of them
+	head := make([]int, n+1)
+	from := make([]int, n+1)
+	for j := 1; j <= n; j++ {
+		head[j] = impossible
+		for i := max(0, j-l.maxLength); i < j; i++ {
+			if head[i] != impossible && l.content[string(runes[i:j])] && (head[j] == impossible || head[i]+1 < head[j]) {
+				head[j] = head[i] + 1
+				from[j] = i
+			}
+		}
+	}
+
+	best := impossible
+	for k := n; k >= 1; k-- {
+		if head[k] == impossible || tail[k] == impossible {
+			continue
+		}
+		// fewest morphemes first, then fewest content morphemes
+		if best == impossible || head[k]+tail[k] < head[best]+tail[best] ||
+			(head[k]+tail[k] == head[best]+tail[best] && head[k] < head[best]) {
+			best = k
+		}
+	}
+	if best != impossible {
+		morphemes := make([]string, head[best])
+		for j, m := best, head[best]-1; j > 0; j, m = from[j], m-1 {
+			morphemes[m] = string(runes[from[j]:j])
+		}
+		return morphemes
+	}
+
+	if tail[0] != impossible {
+		return []string{}
+	}
+	// an unknown word, strip its longest known tail
+	for k := MIN_UNKNOWN_NOUN_LENGTH; k < n; k++ {
+		if tail[k] != impossible {
+			return []string{string(runes[:k])}
+		}
+	}
+	return []string{word}
+}
+
+// KoreanTokenizer splits the Hangul words of a text into their content morphemes (see KoreanLexicon.Split),
+// and the rest of the text with another tokenizer.
+type KoreanTokenizer struct {
+	Lexicon *KoreanLexicon
+	// Fallback tokenizes the text between the Hangul words, WhitespaceTokenizer if nil.
+	Fallback Tokenizer
+}
+
+/**
+ * Create a Korean tokenizer with the bundled lexicon.
+ *
+ * @param fallback The tokenizer of the text that is not Hangul, WhitespaceTokenizer if nil
+ * @return *KoreanTokenizer
+ */
+func NewKoreanTokenizer(fallback Tokenizer) *KoreanTokenizer {
+	return &KoreanTokenizer{Lexicon: DefaultKoreanLexicon(), Fallback: fallback}
+}
+
+func (t *KoreanTokenizer) Tokenize(text string) []Token {
+	return tokenizeScript(text, isHangulSyllable, func(word []rune) []Token {
+		return newTokens(t.Lexicon.Split(string(word)))
+	}, t.Fallback)
+}
+
+func isHangulSyllable(r rune) bool {
+	return r >= 0xAC00 && r <= 0xD7A3
+}
+
+/**
+ * Create an analyzer for Korean text: normalize the text (see NormalizationFilters), and split the Hangul words
+ * into their nouns and stems, without the particles and the endings (e.g. 아이유는 -> 아이유).
+ *
+ * @return *Analyzer
+ */
+func NewKoreanAnalyzer() *Analyzer {
+	return NewAnalyzer(NormalizationFilters(false), NewKoreanTokenizer(nil))
+}
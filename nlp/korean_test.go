@@ -0,0 +1,69 @@
+package nlp
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKoreanLexiconSplit(t *testing.T) {
+	lexicon := DefaultKoreanLexicon()
+	cases := map[string][]string{
+		"아이유":   {"아이유"},
+		"아이유는":  {"아이유"},
+		"대한민국의": {"대한민국"},
+		"가수이자":  {"가수"},
+		"배우이다":  {"배우"},
+		"사용한다":  {"사용"},
+		"음악으로":  {"음악"},
+		"합성어로":  {"합성어"},
+		// compound nouns are split into the nouns of the lexicon
+		"검색엔진을": {"검색", "엔진"},
+		// unknown nouns are stripped from their known particles
+		"뉴진스가": {"뉴진스"},
+		"뉴진스":  {"뉴진스"},
+		// but not below MIN_UNKNOWN_NOUN_LENGTH syllables
+		"쥐가": {"쥐가"},
+		// particles alone have no content
+		"는": {},
+	}
+	for word, expected := range cases {
+		if morphemes := lexicon.Split(word); !reflect.DeepEqual(morphemes, expected) {
+			t.Errorf("%s: expected %v, got %v", word, expected, morphemes)
+		}
+	}
+}
+
+func TestKoreanAnalyzer(t *testing.T) {
+	analyzer := NewKoreanAnalyzer()
+	terms := Terms(analyzer.Analyze("아이유(IU, 본명: 이지은)는 대한민국의 가수이자 배우이다."))
+	expected := []string{"아이유", "(iu,", "본명", ":", "이지은", ")", "대한민국", "가수", "배우", "."}
+	if !reflect.DeepEqual(terms, expected) {
+		t.Errorf("Expected %v, got %v", expected, terms)
+	}
+
+	// the query and the document share their terms
+	if query := Terms(analyzer.Analyze("아이유")); !reflect.DeepEqual(query, []string{"아이유"}) {
+		t.Errorf("Expected [아이유], got %v", query)
+	}
+
+	tokens := analyzer.Analyze("아이유는 가수")
+	if tokens[1].Position != 1 {
+		t.Errorf("Expected consecutive positions, got %v", tokens)
+	}
+}
+
+func TestLoadKoreanLexicon(t *testing.T) {
+	lexicon, err := LoadKoreanLexicon(strings.NewReader("고양이,1780,3534,2000,NNG,*,F,고양이,*,*,*,*\n가,0,0,0,JKS,*,F,가,*,*,*,*\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if morphemes := lexicon.Split("고양이가"); !reflect.DeepEqual(morphemes, []string{"고양이"}) {
+		t.Errorf("Expected [고양이], got %v", morphemes)
+	}
+
+	if _, err := LoadKoreanLexicon(strings.NewReader("고양이,1780\n")); !errors.Is(err, ErrInvalidLexicon) {
+		t.Errorf("Expected ErrInvalidLexicon, got %v", err)
+	}
+}
@@ -1,6 +1,8 @@
 package nlp
 
 import (
+	"strings"
+
 	"github.com/pemistahl/lingua-go"
 )
 
@@ -25,3 +27,33 @@ func DetectLanguage(text string) (string, bool) {
 	language, exists := detector.DetectLanguageOf(text)
 	return language.String(), exists
 }
+
+/**
+ * Return the ISO 639-1 code of a language.
+ *
+ * @param language A language name (e.g. `English`, as returned by DetectLanguage, or `english`) or an ISO 639-1 code (e.g. `en`)
+ * @return (string, bool) the lowercase code (e.g. `en`), exists
+ */
+func LanguageCode(language string) (string, bool) {
+	for _, candidate := range lingua.AllLanguages() {
+		code := strings.ToLower(candidate.IsoCode639_1().String())
+		if strings.EqualFold(candidate.String(), language) || code == strings.ToLower(language) {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+/**
+ * Detect the language of a given text, with the confidence of the detection.
+ *
+ * @param text string
+ * @return (string, float64, bool) language, confidence between 0 and 1, exists
+ */
+func DetectLanguageWithConfidence(text string) (string, float64, bool) {
+	values := detector.ComputeLanguageConfidenceValues(text)
+	if len(values) == 0 || values[0].Value() == 0 {
+		return "", 0, false
+	}
+	return values[0].Language().String(), values[0].Value(), true
+}
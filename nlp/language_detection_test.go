@@ -0,0 +1,29 @@
+package nlp
+
+import (
+	"testing"
+)
+
+func TestLanguageCode(t *testing.T) {
+	for language, expected := range map[string]string{"English": "en", "french": "fr", "DE": "de", "ko": "ko"} {
+		if code, exists := LanguageCode(language); !exists || code != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, language, code)
+		}
+	}
+	if code, exists := LanguageCode("Klingon"); exists {
+		t.Errorf("Expected no code for an unknown language, got %s", code)
+	}
+}
+
+func TestDetectLanguageWithConfidence(t *testing.T) {
+	language, confidence, exists := DetectLanguageWithConfidence("Les chanteuses chantent des chansons dans la rue")
+	if !exists || language != "French" {
+		t.Errorf("Expected French, got %s", language)
+	}
+	if confidence <= 0.5 || confidence > 1 {
+		t.Errorf("Expected a high confidence, got %f", confidence)
+	}
+	if _, _, exists := DetectLanguageWithConfidence("1234 !?"); exists {
+		t.Error("Expected no language for a text without letters")
+	}
+}
@@ -0,0 +1,68 @@
+package nlp
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NFKCFilter is a char filter applying the Unicode NFKC normalization, so that the compatibility variants of
+// a character match it, e.g. the full-width `ＡＢＣ１２３` and `ABC123`, the ligature `ﬁ` and `fi`, or `①` and `1`.
+var NFKCFilter = CharFilterFunc(norm.NFKC.String)
+
+// CaseFoldFilter is a char filter applying the full Unicode case folding, which unlike strings.ToLower also maps
+// the characters whose lowercase is longer, e.g. `Straße` and `STRASSE` both give `strasse`.
+// The dotted capital I of Turkish gives a plain `i`.
+var CaseFoldFilter = CharFilterFunc(func(text string) string {
+	// a Caser is stateful, so it cannot be shared
+	folded := cases.Fold().String(text)
+	// İ folds into i followed by a combining dot above, which is redundant on an i
+	return strings.ReplaceAll(folded, "i̇", "i")
+})
+
+// DiacriticFilter is a char filter removing the diacritics of the Latin and Greek letters,
+// e.g. `café` gives `cafe` and `Ελλάδα` gives `Ελλαδα`. The letters of the other scripts are kept as they are,
+// as their marks are part of the letter (e.g. the dakuten of the Japanese `が`).
+var DiacriticFilter = CharFilterFunc(stripDiacritics)
+
+// strippedLetters are the letters with a diacritic that do not decompose into a base letter and a mark.
+var strippedLetters = map[rune]string{
+	'ı': "i", 'ø': "o", 'Ø': "O", 'ł': "l", 'Ł': "L", 'đ': "d", 'Đ': "D", 'ħ': "h", 'Ħ': "H",
+	'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE",
+}
+
+func stripDiacritics(text string) string {
+	var b strings.Builder
+	var base rune
+	for _, r := range norm.NFD.String(text) {
+		if unicode.Is(unicode.Mn, r) {
+			if unicode.In(base, unicode.Latin, unicode.Greek) {
+				continue
+			}
+		} else {
+			base = r
+		}
+		if stripped, ok := strippedLetters[r]; ok {
+			b.WriteString(stripped)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
+
+/**
+ * Return the char filters normalizing the text: NFKC normalization, case folding, and optionally diacritic stripping.
+ *
+ * @param stripDiacritics Whether the diacritics are removed as well, e.g. so that `café` matches `cafe`
+ * @return []CharFilter
+ */
+func NormalizationFilters(stripDiacritics bool) []CharFilter {
+	filters := []CharFilter{NFKCFilter, CaseFoldFilter}
+	if stripDiacritics {
+		filters = append(filters, DiacriticFilter)
+	}
+	return filters
+}
@@ -0,0 +1,45 @@
+package nlp
+
+import (
+	"testing"
+)
+
+func TestNormalizationFilters(t *testing.T) {
+	cases := []struct {
+		filter   CharFilter
+		text     string
+		expected string
+	}{
+		{NFKCFilter, "ＡＢＣ１２３", "ABC123"},
+		{NFKCFilter, "ﬁle ①", "file 1"},
+		// the jamo sequences are composed into syllables
+		{NFKCFilter, "아이", "아이"},
+		{CaseFoldFilter, "Straße STRASSE", "strasse strasse"},
+		{CaseFoldFilter, "İstanbul ISTANBUL", "istanbul istanbul"},
+		{CaseFoldFilter, "ΣΊΣΥΦΟΣ", "σίσυφοσ"},
+		{DiacriticFilter, "Café crème brûlée", "Cafe creme brulee"},
+		{DiacriticFilter, "Łódź Øresund ışık", "Lodz Oresund isik"},
+		{DiacriticFilter, "Ελλάδα", "Ελλαδα"},
+		// the marks of the other scripts are part of their letters
+		{DiacriticFilter, "がぎ 아이유 й", "がぎ 아이유 й"},
+	}
+	for _, c := range cases {
+		if normalized := c.filter.Filter(c.text); normalized != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.text, c.expected, normalized)
+		}
+	}
+}
+
+func TestNormalizationAnalyzer(t *testing.T) {
+	analyzer := NewAnalyzer(NormalizationFilters(true), WhitespaceTokenizer{})
+	for _, text := range []string{"CAFÉ", "café", "cafe", "ｃａｆｅ", "Café"} {
+		if terms := Terms(analyzer.Analyze(text)); len(terms) != 1 || terms[0] != "cafe" {
+			t.Errorf("%s: expected [cafe], got %v", text, terms)
+		}
+	}
+
+	analyzer = NewAnalyzer(NormalizationFilters(false), WhitespaceTokenizer{})
+	if terms := Terms(analyzer.Analyze("CAFÉ")); terms[0] != "café" {
+		t.Errorf("Expected the diacritics to be kept, got %v", terms)
+	}
+}
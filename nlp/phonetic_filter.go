@@ -0,0 +1,138 @@
+package nlp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	// the phonetic encoders of PhoneticFilter
+	PHONETIC_SOUNDEX          = "soundex"
+	PHONETIC_DOUBLE_METAPHONE = "double_metaphone"
+
+	// SOUNDEX_LENGTH is the length of the Soundex codes.
+	SOUNDEX_LENGTH = 4
+)
+
+var ErrUnknownPhoneticEncoder = errors.New("unknown phonetic encoder")
+
+// soundexDigits are the digits of the consonants in the Soundex codes, the other letters have none.
+var soundexDigits = map[rune]byte{
+	'B': '1', 'F': '1', 'P': '1', 'V': '1',
+	'C': '2', 'G': '2', 'J': '2', 'K': '2', 'Q': '2', 'S': '2', 'X': '2', 'Z': '2',
+	'D': '3', 'T': '3',
+	'L': '4',
+	'M': '5', 'N': '5',
+	'R': '6',
+}
+
+/**
+ * Encode a word with the American Soundex: its first letter followed by the digits of the next consonants,
+ * the consonants sounding alike sharing a digit, e.g. `Robert` and `Rupert` give R163.
+ * The letters other than A to Z are ignored, e.g. the diacritics must be stripped beforehand.
+ *
+ * @param word string
+ * @return string the code of SOUNDEX_LENGTH characters, padded with zeros, empty if the word has no letter
+ */
+func Soundex(word string) string {
+	code := make([]byte, 0, SOUNDEX_LENGTH)
+	var last byte
+	for _, r := range strings.ToUpper(word) {
+		if r < 'A' || r > 'Z' {
+			continue
+		}
+		digit := soundexDigits[r]
+		if len(code) == 0 {
+			code = append(code, byte(r))
+			last = digit
+			continue
+		}
+		switch {
+		case r == 'H' || r == 'W':
+			// H and W do not separate the consonants sharing a digit, e.g. `Ashcraft` gives A261
+		case digit == 0:
+			// the vowels do, e.g. `Tymczak` gives T522
+			last = 0
+		case digit != last:
+			code = append(code, digit)
+			last = digit
+		}
+		if len(code) == SOUNDEX_LENGTH {
+			break
+		}
+	}
+	if len(code) == 0 {
+		return ""
+	}
+	for len(code) < SOUNDEX_LENGTH {
+		code = append(code, '0')
+	}
+	return string(code)
+}
+
+// SupportsPhoneticEncoder returns whether PhoneticFilter supports an encoder, e.g. PHONETIC_SOUNDEX.
+func SupportsPhoneticEncoder(encoder string) bool {
+	return encoder == PHONETIC_SOUNDEX || encoder == PHONETIC_DOUBLE_METAPHONE
+}
+
+// PhoneticFilter is a token filter replacing the words with their phonetic codes, so that the words sounding alike
+// match each other, e.g. `Ishmael` and `Ismael`. With Double Metaphone, the alternate code of a word is added at
+// its position when it differs from the primary code. The words without a Latin letter are removed.
+type PhoneticFilter struct {
+	// Encoder is PHONETIC_SOUNDEX or PHONETIC_DOUBLE_METAPHONE.
+	Encoder string
+}
+
+func NewPhoneticFilter(encoder string) *PhoneticFilter {
+	return &PhoneticFilter{Encoder: encoder}
+}
+
+func (f *PhoneticFilter) Filter(tokens []Token) []Token {
+	encoded := make([]Token, 0, len(tokens))
+	for _, token := range tokens {
+		switch f.Encoder {
+		case PHONETIC_SOUNDEX:
+			if code := Soundex(token.Term); code != "" {
+				encoded = append(encoded, Token{Term: code, Position: token.Position})
+			}
+		case PHONETIC_DOUBLE_METAPHONE:
+			primary, alternate := DoubleMetaphone(token.Term)
+			if primary != "" {
+				encoded = append(encoded, Token{Term: primary, Position: token.Position})
+			}
+			if alternate != "" && alternate != primary {
+				encoded = append(encoded, Token{Term: alternate, Position: token.Position})
+			}
+		}
+	}
+	return encoded
+}
+
+// wordCharFilter is a char filter replacing the characters other than the letters and the digits with spaces,
+// so that the punctuation is not attached to the words split on the spaces.
+var wordCharFilter = CharFilterFunc(func(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, text)
+})
+
+/**
+ * Create an analyzer indexing the phonetic codes of the words (see PhoneticFilter), without their diacritics and
+ * their stopwords. It is meant for a secondary field, where the words sounding alike match each other,
+ * e.g. the misspelled names. It does not depend on the pretrained tokenizer.
+ *
+ * @param encoder PHONETIC_SOUNDEX or PHONETIC_DOUBLE_METAPHONE
+ * @return (*Analyzer, error) the analyzer, ErrUnknownPhoneticEncoder if the encoder is not supported
+ */
+func NewPhoneticAnalyzer(encoder string) (*Analyzer, error) {
+	if !SupportsPhoneticEncoder(encoder) {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPhoneticEncoder, encoder)
+	}
+	charFilters := append(NormalizationFilters(true), wordCharFilter)
+	return NewAnalyzer(charFilters, WhitespaceTokenizer{}, NewStopwordFilter(""), NewPhoneticFilter(encoder)), nil
+}
@@ -0,0 +1,85 @@
+package nlp
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSoundex(t *testing.T) {
+	cases := map[string]string{
+		"Robert":   "R163",
+		"Rupert":   "R163",
+		"Rubin":    "R150",
+		"Ashcraft": "A261",
+		"Tymczak":  "T522",
+		"Pfister":  "P236",
+		"Ishmael":  "I254",
+		"Ismael":   "I254",
+		"Lee":      "L000",
+		"O'Hara":   "O600",
+		"123":      "",
+		"아이유":      "",
+	}
+	for word, expected := range cases {
+		if code := Soundex(word); code != expected {
+			t.Errorf("%s: expected %q, got %q", word, expected, code)
+		}
+	}
+}
+
+func TestDoubleMetaphone(t *testing.T) {
+	cases := []struct {
+		word      string
+		primary   string
+		alternate string
+	}{
+		{"Smith", "SM0", "XMT"},
+		{"Schmidt", "XMT", "SMT"},
+		{"Thomas", "TMS", "TMS"},
+		{"Knight", "NT", "NT"},
+		{"Laugh", "LF", "LF"},
+		{"Michael", "MKL", "MXL"},
+		{"Jose", "HS", "HS"},
+		{"Ishmael", "AXML", "AXML"},
+		{"Catherine", "K0RN", "KTRN"},
+		{"Katherine", "K0RN", "KTRN"},
+		{"Xavier", "SF", "SFR"},
+		{"", "", ""},
+	}
+	for _, c := range cases {
+		if primary, alternate := DoubleMetaphone(c.word); primary != c.primary || alternate != c.alternate {
+			t.Errorf("%s: expected %q and %q, got %q and %q", c.word, c.primary, c.alternate, primary, alternate)
+		}
+	}
+}
+
+func TestPhoneticFilter(t *testing.T) {
+	tokens := []Token{{Term: "smith", Position: 0}, {Term: "아이유", Position: 1}, {Term: "lee", Position: 2}}
+
+	expected := []Token{{Term: "S530", Position: 0}, {Term: "L000", Position: 2}}
+	if encoded := NewPhoneticFilter(PHONETIC_SOUNDEX).Filter(tokens); !reflect.DeepEqual(encoded, expected) {
+		t.Errorf("Expected %v, got %v", expected, encoded)
+	}
+	// the alternate code is added at the position of the word
+	expected = []Token{{Term: "SM0", Position: 0}, {Term: "XMT", Position: 0}, {Term: "L", Position: 2}}
+	if encoded := NewPhoneticFilter(PHONETIC_DOUBLE_METAPHONE).Filter(tokens); !reflect.DeepEqual(encoded, expected) {
+		t.Errorf("Expected %v, got %v", expected, encoded)
+	}
+}
+
+func TestPhoneticAnalyzer(t *testing.T) {
+	analyzer, err := NewPhoneticAnalyzer(PHONETIC_SOUNDEX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the stopwords and the punctuation are dropped, and the diacritics stripped
+	terms := Terms(analyzer.AnalyzeLanguage("Ishmael, the café!", "en"))
+	if expected := []string{"I254", "C100"}; !reflect.DeepEqual(terms, expected) {
+		t.Errorf("Expected %v, got %v", expected, terms)
+	}
+
+	if _, err := NewPhoneticAnalyzer("metaphone3"); !errors.Is(err, ErrUnknownPhoneticEncoder) {
+		t.Errorf("Expected ErrUnknownPhoneticEncoder, got %v", err)
+	}
+}
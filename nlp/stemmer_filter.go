@@ -0,0 +1,73 @@
+package nlp
+
+import (
+	"strings"
+
+	"github.com/blevesearch/snowballstem"
+	"github.com/blevesearch/snowballstem/english"
+	"github.com/blevesearch/snowballstem/french"
+	"github.com/blevesearch/snowballstem/german"
+	"github.com/blevesearch/snowballstem/spanish"
+)
+
+// stemmers are the Snowball stemmers of the supported languages, by ISO 639-1 code.
+var stemmers = map[string]func(*snowballstem.Env) bool{
+	"en": english.Stem,
+	"fr": french.Stem,
+	"de": german.Stem,
+	"es": spanish.Stem,
+}
+
+// StemmerFilter is a token filter reducing the words to their stem with the Snowball stemmers (e.g. `dreams`
+// and `dreamed` to `dream`), so that the inflected forms of a word match each other.
+// English, French, German and Spanish are supported, the tokens of the other languages are left as they are.
+type StemmerFilter struct {
+	// Language is the language of the words, either a name (e.g. `german`) or an ISO 639-1 code (e.g. `de`).
+	// If empty, the stemmer of the language of the analyzed text is used.
+	Language string
+}
+
+func NewStemmerFilter(language string) *StemmerFilter {
+	return &StemmerFilter{Language: language}
+}
+
+// SupportsStemming returns whether a language, given by name or ISO 639-1 code, has a stemmer.
+func SupportsStemming(language string) bool {
+	code, _ := LanguageCode(language)
+	_, ok := stemmers[code]
+	return ok
+}
+
+func (f *StemmerFilter) Filter(tokens []Token) []Token {
+	language := f.Language
+	if language == "" {
+		if detectedLanguage, exists := DetectLanguage(strings.Join(Terms(tokens), " ")); exists {
+			language = detectedLanguage
+		}
+	}
+	return f.FilterLanguage(tokens, language)
+}
+
+func (f *StemmerFilter) FilterLanguage(tokens []Token, language string) []Token {
+	if f.Language != "" {
+		language = f.Language
+	}
+	code, _ := LanguageCode(language)
+	stem, ok := stemmers[code]
+	if !ok {
+		return tokens
+	}
+
+	stemmed := make([]Token, len(tokens))
+	for i, token := range tokens {
+		stemmed[i] = token
+		// the continuation pieces of the subword tokenizer (e.g. `##ness`) are not words
+		if strings.HasPrefix(token.Term, "##") {
+			continue
+		}
+		env := snowballstem.NewEnv(token.Term)
+		stem(env)
+		stemmed[i].Term = env.Current()
+	}
+	return stemmed
+}
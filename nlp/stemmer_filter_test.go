@@ -0,0 +1,49 @@
+package nlp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStemmerFilter(t *testing.T) {
+	cases := []struct {
+		language string
+		text     string
+		expected []string
+	}{
+		{"english", "dreams dreamed dreaming", []string{"dream", "dream", "dream"}},
+		{"fr", "chanteuses chanteuse", []string{"chanteux", "chanteux"}},
+		{"German", "häuser haus", []string{"haus", "haus"}},
+		{"es", "canciones canción", []string{"cancion", "cancion"}},
+		// no stemmer for korean, the tokens are left as they are
+		{"korean", "아이유는 가수이다", []string{"아이유는", "가수이다"}},
+	}
+	for _, c := range cases {
+		tokens := NewStemmerFilter(c.language).Filter(WhitespaceTokenizer{}.Tokenize(c.text))
+		if terms := Terms(tokens); !reflect.DeepEqual(terms, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.language, c.expected, terms)
+		}
+	}
+}
+
+func TestStemmerFilterLanguage(t *testing.T) {
+	analyzer := NewAnalyzer([]CharFilter{LowercaseFilter}, WhitespaceTokenizer{}, NewStemmerFilter(""))
+
+	// the stemmer of the given language is used
+	if terms := Terms(analyzer.AnalyzeLanguage("Dreams", "English")); !reflect.DeepEqual(terms, []string{"dream"}) {
+		t.Errorf("Expected [dream], got %v", terms)
+	}
+	// or the one of the detected language
+	terms := Terms(analyzer.Analyze("Die Kinder spielten in den Gärten ihrer Häuser"))
+	if terms[len(terms)-1] != "haus" {
+		t.Errorf("Expected the german stem haus, got %v", terms)
+	}
+	// the subword pieces are not stemmed
+	if terms := Terms(NewStemmerFilter("en").Filter([]Token{{"happi", 0}, {"##ness", 1}})); terms[1] != "##ness" {
+		t.Errorf("Expected ##ness not to be stemmed, got %v", terms)
+	}
+
+	if !SupportsStemming("French") || SupportsStemming("ko") {
+		t.Error("Expected French to be supported and Korean not")
+	}
+}
@@ -0,0 +1,227 @@
+package nlp
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// stopwordLists holds the built-in stopword lists, one <ISO 639-1 code>.txt per language in the format of LoadStopwords,
+// taken from github.com/bbalet/stopwords.
+//
+//go:embed stopwords/*.txt
+var stopwordLists embed.FS
+
+// builtinStopwordCache caches the built-in list of each language code, read once from stopwordLists.
+var builtinStopwordCache sync.Map // map[string]map[string]struct{}
+
+const (
+	// ENV_STOPWORDS is the language of the built-in stopword list, or STOPWORDS_DISABLED to index all the words.
+	ENV_STOPWORDS = "GO4SEARCH_STOPWORDS"
+	// ENV_STOPWORDS_FILE is a custom stopword list replacing the built-in ones, see LoadStopwordFile.
+	ENV_STOPWORDS_FILE = "GO4SEARCH_STOPWORDS_FILE"
+	// ENV_PHRASE_STOPWORDS keeps the stopwords in the phrase queries when set to `1` or `true`.
+	ENV_PHRASE_STOPWORDS = "GO4SEARCH_PHRASE_STOPWORDS"
+
+	// STOPWORDS_DISABLED is the value of GO4SEARCH_STOPWORDS disabling the stopwords.
+	STOPWORDS_DISABLED = "none"
+	// STOPWORD_FILE_COMMENT starts the comments of the stopword files.
+	STOPWORD_FILE_COMMENT = "#"
+)
+
+// StopwordFilter is a token filter removing the stopwords of a language (e.g. `the`, `is`, `at` in English).
+// The tokens made of punctuation only are removed as well.
+type StopwordFilter struct {
+	// Language is the language of the stopwords, either a name (e.g. `english`) or an ISO 639-1 code (e.g. `en`).
+	// If empty, the stopwords of the language of the analyzed text are removed.
+	Language string
+	// Words is a custom list of lowercase stopwords, which replaces the built-in list of the language if not nil.
+	Words map[string]bool
+}
+
+func NewStopwordFilter(language string) *StopwordFilter {
+	return &StopwordFilter{Language: language}
+}
+
+/**
+ * Create a stopword filter removing the given words only, whatever the language of the text.
+ * Without words, only the tokens made of punctuation are removed.
+ *
+ * @param words The stopwords, matched case-insensitively
+ * @return *StopwordFilter
+ */
+func NewCustomStopwordFilter(words []string) *StopwordFilter {
+	filter := &StopwordFilter{Words: make(map[string]bool, len(words))}
+	for _, word := range words {
+		filter.Words[strings.ToLower(word)] = true
+	}
+	return filter
+}
+
+// removes returns whether the filter removes words, i.e. uses the built-in list of a language or a non-empty custom list.
+func (f *StopwordFilter) removes() bool {
+	return f.Words == nil || len(f.Words) > 0
+}
+
+func (f *StopwordFilter) Filter(tokens []Token) []Token {
+	language := f.Language
+	if language == "" && f.Words == nil {
+		if detectedLanguage, exists := DetectLanguage(strings.Join(Terms(tokens), " ")); exists {
+			language = detectedLanguage
+		}
+	}
+	return f.FilterLanguage(tokens, language)
+}
+
+func (f *StopwordFilter) FilterLanguage(tokens []Token, language string) []Token {
+	if f.Language != "" {
+		language = f.Language
+	}
+	var words map[string]struct{}
+	if code, exists := LanguageCode(language); exists && f.Words == nil {
+		words = builtinStopwords(code)
+	}
+
+	filtered := make([]Token, 0, len(tokens))
+	for _, token := range tokens {
+		word := strings.TrimFunc(token.Term, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if word == "" {
+			continue
+		}
+		if f.Words != nil {
+			if f.Words[strings.ToLower(word)] {
+				continue
+			}
+		} else if _, ok := words[norm.NFC.String(strings.ToLower(word))]; ok {
+			continue
+		}
+		filtered = append(filtered, token)
+	}
+	return filtered
+}
+
+// builtinStopwords returns the built-in stopwords of a language code (e.g. `en`), empty if it has no list.
+// The list is read once, and shared by all the filters.
+func builtinStopwords(code string) map[string]struct{} {
+	if words, ok := builtinStopwordCache.Load(code); ok {
+		return words.(map[string]struct{})
+	}
+
+	words := make(map[string]struct{})
+	if file, err := stopwordLists.Open("stopwords/" + code + ".txt"); err == nil {
+		defer file.Close()
+		// the embedded lists are well-formed
+		list, _ := LoadStopwords(file)
+		for _, word := range list {
+			words[word] = struct{}{}
+		}
+	}
+	cached, _ := builtinStopwordCache.LoadOrStore(code, words)
+	return cached.(map[string]struct{})
+}
+
+// StopwordConfig describes the stopwords removed from a field, see StopwordConfig.Filter.
+type StopwordConfig struct {
+	// Disabled keeps all the words, only the tokens made of punctuation are removed.
+	Disabled bool
+	// Language is the language of the built-in list, either a name or an ISO 639-1 code.
+	// If empty, the list of the language of each document and query is used.
+	Language string
+	// File is a file of custom stopwords, one per line, with `#` comments. The custom stopwords replace the built-in list.
+	File string
+	// Words are custom stopwords, added to the ones of File.
+	Words []string
+	// KeepInPhrases keeps the stopwords in the phrase queries, so that e.g. "the hobbit" does not match "a hobbit".
+	KeepInPhrases bool
+}
+
+/**
+ * Read the stopword configuration from the environment:
+ * GO4SEARCH_STOPWORDS (a language, or `none` to disable the stopwords), GO4SEARCH_STOPWORDS_FILE (a custom list)
+ * and GO4SEARCH_PHRASE_STOPWORDS (e.g. `1` or `true` to keep the stopwords in the phrase queries).
+ *
+ * @return StopwordConfig
+ */
+func StopwordConfigFromEnv() StopwordConfig {
+	config := StopwordConfig{File: os.Getenv(ENV_STOPWORDS_FILE)}
+	if language := os.Getenv(ENV_STOPWORDS); strings.EqualFold(language, STOPWORDS_DISABLED) {
+		config.Disabled = true
+	} else {
+		config.Language = language
+	}
+	config.KeepInPhrases, _ = strconv.ParseBool(os.Getenv(ENV_PHRASE_STOPWORDS))
+	return config
+}
+
+/**
+ * Create the stopword filter described by the configuration.
+ *
+ * @return (*StopwordFilter, error) the filter, an error if the stopword file cannot be read or the language is unknown
+ */
+func (c StopwordConfig) Filter() (*StopwordFilter, error) {
+	if c.Disabled {
+		return NewCustomStopwordFilter(nil), nil
+	}
+	if c.File == "" && len(c.Words) == 0 {
+		if _, exists := LanguageCode(c.Language); c.Language != "" && !exists {
+			return nil, fmt.Errorf("unknown stopword language %q", c.Language)
+		}
+		return NewStopwordFilter(c.Language), nil
+	}
+
+	words := c.Words
+	if c.File != "" {
+		fileWords, err := LoadStopwordFile(c.File)
+		if err != nil {
+			return nil, err
+		}
+		words = append(fileWords, words...)
+	}
+	return NewCustomStopwordFilter(words), nil
+}
+
+/**
+ * Read a list of stopwords, one per line. The empty lines and the text after `#` are ignored.
+ *
+ * @param r The list of stopwords
+ * @return ([]string, error) the stopwords
+ */
+func LoadStopwords(r io.Reader) ([]string, error) {
+	words := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), STOPWORD_FILE_COMMENT)
+		if word := strings.TrimSpace(line); word != "" {
+			words = append(words, word)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+/**
+ * Read a file of stopwords, see LoadStopwords.
+ *
+ * @param path The path of the file
+ * @return ([]string, error) the stopwords
+ */
+func LoadStopwordFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the stopwords: %w", err)
+	}
+	defer file.Close()
+	return LoadStopwords(file)
+}
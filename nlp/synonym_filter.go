@@ -0,0 +1,287 @@
+package nlp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ENV_SYNONYMS_FILE is the path of the synonym file (Solr or WordNet format), no synonyms if it is not set.
+	ENV_SYNONYMS_FILE = "GO4SEARCH_SYNONYMS_FILE"
+	// ENV_INDEX_SYNONYMS indexes the synonyms along with the words when set to `1` or `true`,
+	// instead of expanding the queries with them.
+	ENV_INDEX_SYNONYMS = "GO4SEARCH_INDEX_SYNONYMS"
+)
+
+var ErrInvalidSynonyms = errors.New("invalid synonyms")
+
+// wordNetSynonymPattern matches a line of the prolog files of WordNet (wn_s.pl), e.g. `s(100001740,1,'entity',n,1,11).`
+var wordNetSynonymPattern = regexp.MustCompile(`^s\((\d+),\d+,'((?:[^']|'')*)',`)
+
+// synonymRule is the alternatives of a sequence of words.
+type synonymRule struct {
+	alternatives [][]string
+	keepOriginal bool // whether the words are kept along with their alternatives, false for the one-way synonyms
+}
+
+// SynonymMap holds the synonyms of words and of sequences of words, e.g. `usa` and `united states`.
+type SynonymMap struct {
+	rules    map[string]*synonymRule // the rules by sequence of words joined with a space
+	maxWords int                     // the number of words of the longest sequence
+}
+
+func NewSynonymMap() *SynonymMap {
+	return &SynonymMap{rules: make(map[string]*synonymRule)}
+}
+
+// synonymWords splits an entry of a synonym file into its lowercase words.
+func synonymWords(entry string) []string {
+	return strings.Fields(strings.ToLower(entry))
+}
+
+/**
+ * Add equivalent synonyms: each of them is expanded into all the others, and kept.
+ * e.g. `couch`, `sofa`, `settee`
+ *
+ * @param entries The synonyms, each one a word or a sequence of words separated by spaces
+ */
+func (m *SynonymMap) AddEquivalent(entries ...string) {
+	for i, entry := range entries {
+		alternatives := make([][]string, 0, len(entries)-1)
+		for j, other := range entries {
+			if j != i {
+				alternatives = append(alternatives, synonymWords(other))
+			}
+		}
+		m.add(synonymWords(entry), alternatives, true)
+	}
+}
+
+/**
+ * Add one-way synonyms: each of the entries is replaced with the alternatives, but not the other way around.
+ * e.g. `i-pod`, `i pod` => `ipod`. An entry is kept if it is one of the alternatives too.
+ *
+ * @param entries The words or sequences of words to replace
+ * @param alternatives The words or sequences of words replacing them
+ */
+func (m *SynonymMap) AddMapping(entries []string, alternatives []string) {
+	words := make([][]string, 0, len(alternatives))
+	for _, alternative := range alternatives {
+		words = append(words, synonymWords(alternative))
+	}
+	for _, entry := range entries {
+		m.add(synonymWords(entry), words, false)
+	}
+}
+
+func (m *SynonymMap) add(words []string, alternatives [][]string, keepOriginal bool) {
+	if len(words) == 0 {
+		return
+	}
+	key := strings.Join(words, " ")
+	rule, ok := m.rules[key]
+	if !ok {
+		rule = &synonymRule{}
+		m.rules[key] = rule
+	}
+	rule.keepOriginal = rule.keepOriginal || keepOriginal
+	for _, alternative := range alternatives {
+		if len(alternative) == 0 {
+			continue
+		}
+		if strings.Join(alternative, " ") == key {
+			rule.keepOriginal = true
+			continue
+		}
+		rule.alternatives = append(rule.alternatives, alternative)
+	}
+	m.maxWords = max(m.maxWords, len(words))
+}
+
+// Len returns the number of words and sequences of words having synonyms.
+func (m *SynonymMap) Len() int {
+	return len(m.rules)
+}
+
+/**
+ * Read synonyms in the format of Solr, or in the prolog format of WordNet (wn_s.pl).
+ *
+ * In the format of Solr, each line is either a list of equivalent synonyms separated by commas (`couch, sofa, settee`),
+ * or one-way synonyms (`i-pod, i pod => ipod`). A synonym can be made of several words (`usa, united states`).
+ * The empty lines and the lines starting with `#` are ignored.
+ *
+ * In the format of WordNet, the words of a synset are equivalent synonyms,
+ * e.g. `s(102121620,1,'cat',n,1,18).` and `s(102121620,2,'true cat',n,1,0).`
+ *
+ * @param r The synonyms
+ * @return (*SynonymMap, error) the synonyms, ErrInvalidSynonyms if a line is malformed
+ */
+func LoadSynonyms(r io.Reader) (*SynonymMap, error) {
+	synonyms := NewSynonymMap()
+	synsets := make(map[string][]string)
+	synsetIDs := make([]string, 0)
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(text, "s(") {
+			match := wordNetSynonymPattern.FindStringSubmatch(text)
+			if match == nil {
+				return nil, fmt.Errorf("%w: line %d is not a WordNet synonym", ErrInvalidSynonyms, line)
+			}
+			if _, ok := synsets[match[1]]; !ok {
+				synsetIDs = append(synsetIDs, match[1])
+			}
+			synsets[match[1]] = append(synsets[match[1]], strings.ReplaceAll(match[2], "''", "'"))
+			continue
+		}
+
+		if entries, alternatives, ok := strings.Cut(text, "=>"); ok {
+			from, to := splitSynonyms(entries), splitSynonyms(alternatives)
+			if len(from) == 0 || len(to) == 0 {
+				return nil, fmt.Errorf("%w: line %d has no synonym on one side of =>", ErrInvalidSynonyms, line)
+			}
+			synonyms.AddMapping(from, to)
+			continue
+		}
+		synonyms.AddEquivalent(splitSynonyms(text)...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(synsetIDs)
+	for _, id := range synsetIDs {
+		synonyms.AddEquivalent(synsets[id]...)
+	}
+	return synonyms, nil
+}
+
+// splitSynonyms splits a comma separated list of synonyms, dropping the empty ones.
+func splitSynonyms(list string) []string {
+	synonyms := make([]string, 0)
+	for _, synonym := range strings.Split(list, ",") {
+		if synonym = strings.TrimSpace(synonym); synonym != "" {
+			synonyms = append(synonyms, synonym)
+		}
+	}
+	return synonyms
+}
+
+/**
+ * Read a file of synonyms, see LoadSynonyms.
+ *
+ * @param path The path of the file
+ * @return (*SynonymMap, error) the synonyms
+ */
+func LoadSynonymFile(path string) (*SynonymMap, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the synonyms: %w", err)
+	}
+	defer file.Close()
+	return LoadSynonyms(file)
+}
+
+// SynonymConfig describes the synonyms of a search engine, see SynonymConfig.Load.
+type SynonymConfig struct {
+	// File is a synonym file, see LoadSynonyms. There are no synonyms if it is empty.
+	File string
+	// Index indexes the synonyms along with the words of the documents, instead of expanding the queries with them.
+	Index bool
+}
+
+/**
+ * Read the synonym configuration from the environment:
+ * GO4SEARCH_SYNONYMS_FILE and GO4SEARCH_INDEX_SYNONYMS (e.g. `1` or `true` to index the synonyms).
+ *
+ * @return SynonymConfig
+ */
+func SynonymConfigFromEnv() SynonymConfig {
+	index, _ := strconv.ParseBool(os.Getenv(ENV_INDEX_SYNONYMS))
+	return SynonymConfig{File: os.Getenv(ENV_SYNONYMS_FILE), Index: index}
+}
+
+/**
+ * Load the synonyms described by the configuration.
+ *
+ * @return (*SynonymMap, error) the synonyms, nil if there is no synonym file, an error if it cannot be read
+ */
+func (c SynonymConfig) Load() (*SynonymMap, error) {
+	if c.File == "" {
+		return nil, nil
+	}
+	return LoadSynonymFile(c.File)
+}
+
+// SynonymFilter is a token filter adding the synonyms of the words, at the positions of the words they replace.
+// The longest sequence of words having synonyms is replaced first, e.g. `united states` rather than `states`.
+// It must come before the stopword and the stemmer filters, since the synonyms are matched as written.
+type SynonymFilter struct {
+	Synonyms *SynonymMap
+}
+
+func NewSynonymFilter(synonyms *SynonymMap) *SynonymFilter {
+	return &SynonymFilter{Synonyms: synonyms}
+}
+
+func (f *SynonymFilter) Filter(tokens []Token) []Token {
+	if f.Synonyms == nil || f.Synonyms.Len() == 0 {
+		return tokens
+	}
+
+	expanded := make([]Token, 0, len(tokens))
+	for i := 0; i < len(tokens); {
+		length, rule := f.match(tokens[i:])
+		if rule == nil {
+			expanded = append(expanded, tokens[i])
+			i++
+			continue
+		}
+
+		if rule.keepOriginal {
+			expanded = append(expanded, tokens[i:i+length]...)
+		}
+		start := tokens[i].Position
+		for _, alternative := range rule.alternatives {
+			for k, word := range alternative {
+				expanded = append(expanded, Token{Term: word, Position: start + k})
+			}
+		}
+		i += length
+	}
+
+	sort.SliceStable(expanded, func(i, j int) bool {
+		return expanded[i].Position < expanded[j].Position
+	})
+	return expanded
+}
+
+// match returns the longest sequence of consecutive tokens at the start of the tokens having synonyms,
+// as its number of tokens and its rule, or a nil rule if there is none.
+func (f *SynonymFilter) match(tokens []Token) (int, *synonymRule) {
+	words := make([]string, 0, f.Synonyms.maxWords)
+	for k := 0; k < len(tokens) && k < f.Synonyms.maxWords; k++ {
+		if k > 0 && tokens[k].Position != tokens[k-1].Position+1 {
+			break
+		}
+		words = append(words, strings.ToLower(tokens[k].Term))
+	}
+	for length := len(words); length > 0; length-- {
+		if rule, ok := f.Synonyms.rules[strings.Join(words[:length], " ")]; ok {
+			return length, rule
+		}
+	}
+	return 0, nil
+}
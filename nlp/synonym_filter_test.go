@@ -0,0 +1,126 @@
+package nlp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSynonyms = `
+# equivalent synonyms
+couch, sofa, settee
+USA, United States, united states of america
+
+# one-way synonyms
+i-pod, i pod => ipod
+hobbit => hobbit, halfling
+`
+
+func TestSynonymFilter(t *testing.T) {
+	synonyms, err := LoadSynonyms(strings.NewReader(testSynonyms))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	filter := NewSynonymFilter(synonyms)
+
+	tests := []struct {
+		text     string
+		expected []Token
+	}{
+		{"my sofa", []Token{{"my", 0}, {"sofa", 1}, {"couch", 1}, {"settee", 1}}},
+		{"an i pod", []Token{{"an", 0}, {"ipod", 1}}},
+		{"the hobbit", []Token{{"the", 0}, {"hobbit", 1}, {"halfling", 1}}},
+		// the longest sequence is replaced, and the words of the multi-word synonyms follow each other
+		{"united states of america", []Token{
+			{"united", 0}, {"usa", 0}, {"united", 0}, {"states", 1}, {"states", 1}, {"of", 2}, {"america", 3},
+		}},
+		{"in the united states", []Token{
+			{"in", 0}, {"the", 1}, {"united", 2}, {"usa", 2}, {"united", 2}, {"states", 3}, {"states", 3}, {"of", 4}, {"america", 5},
+		}},
+	}
+	for _, test := range tests {
+		if tokens := filter.Filter(WhitespaceTokenizer{}.Tokenize(test.text)); !reflect.DeepEqual(tokens, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.text, test.expected, tokens)
+		}
+	}
+}
+
+func TestLoadSynonyms(t *testing.T) {
+	synonyms, err := LoadSynonyms(strings.NewReader(testSynonyms))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// couch, sofa, settee, usa, united states, united states of america, i-pod, i pod and hobbit
+	if synonyms.Len() != 9 {
+		t.Errorf("Expected 9 words with synonyms, got %d", synonyms.Len())
+	}
+	// the one-way synonyms are not expanded the other way around
+	if tokens := NewSynonymFilter(synonyms).Filter(WhitespaceTokenizer{}.Tokenize("ipod halfling")); len(tokens) != 2 {
+		t.Errorf("Expected no synonym, got %v", tokens)
+	}
+
+	wordNet := `s(102121620,1,'cat',n,1,18).
+s(102121620,2,'true cat',n,1,0).
+s(109906986,1,'o''brien',n,1,0).
+s(109906986,2,'obrien',n,1,0).
+`
+	synonyms, err = LoadSynonyms(strings.NewReader(wordNet))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []Token{{"o'brien", 0}, {"obrien", 0}, {"cat", 1}, {"true", 1}, {"cat", 2}}
+	if tokens := NewSynonymFilter(synonyms).Filter(WhitespaceTokenizer{}.Tokenize("o'brien cat")); !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected %v, got %v", expected, tokens)
+	}
+
+	for _, invalid := range []string{"sofa =>", "s(1,1,cat,n,1,0)."} {
+		if _, err := LoadSynonyms(strings.NewReader(invalid)); !errors.Is(err, ErrInvalidSynonyms) {
+			t.Errorf("%s: expected ErrInvalidSynonyms, got %v", invalid, err)
+		}
+	}
+}
+
+func TestSynonymConfigFromEnv(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "synonyms.txt")
+	if err := os.WriteFile(file, []byte(testSynonyms), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ENV_SYNONYMS_FILE, file)
+	t.Setenv(ENV_INDEX_SYNONYMS, "1")
+
+	config := SynonymConfigFromEnv()
+	if config.File != file || !config.Index {
+		t.Errorf("Unexpected configuration %+v", config)
+	}
+	if synonyms, err := config.Load(); err != nil || synonyms.Len() != 9 {
+		t.Errorf("Expected 9 words with synonyms, got %v and %v", synonyms, err)
+	}
+
+	if synonyms, err := (SynonymConfig{}).Load(); synonyms != nil || err != nil {
+		t.Errorf("Expected no synonyms without file, got %v and %v", synonyms, err)
+	}
+	if _, err := (SynonymConfig{File: filepath.Join(t.TempDir(), "missing.txt")}).Load(); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestAnalyzerWithSynonyms(t *testing.T) {
+	synonyms := NewSynonymMap()
+	synonyms.AddEquivalent("dreams", "visions")
+	analyzer := NewAnalyzer([]CharFilter{LowercaseFilter}, WhitespaceTokenizer{}, NewStopwordFilter("english"), NewStemmerFilter("english"))
+
+	// the synonyms are added before the stopwords and the stemmer
+	withSynonyms := analyzer.WithSynonyms(NewSynonymFilter(synonyms))
+	if _, ok := withSynonyms.TokenFilters[0].(*SynonymFilter); !ok {
+		t.Fatalf("Expected the synonym filter first, got %v", withSynonyms.TokenFilters)
+	}
+	if terms := Terms(withSynonyms.Analyze("The Dreams")); !reflect.DeepEqual(terms, []string{"dream", "vision"}) {
+		t.Errorf("Expected the stems of the synonyms, got %v", terms)
+	}
+	if filters := withSynonyms.WithSynonyms(nil).TokenFilters; len(filters) != 2 {
+		t.Errorf("Expected the synonym filter to be removed, got %v", filters)
+	}
+}
@@ -1,31 +1,259 @@
 package nlp
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
 	"github.com/sugarme/tokenizer"
 	"github.com/sugarme/tokenizer/pretrained"
 )
 
+const (
+	// DEFAULT_TOKENIZER_MODEL is the pretrained tokenizer used when none is configured.
+	// It can be any Hugging Face model with a `tokenizer.json` available, e.g. `bert-base-uncased` or `tiiuae/falcon-7b`.
+	DEFAULT_TOKENIZER_MODEL = "bert-base-multilingual-cased"
+	TOKENIZER_FILE          = "tokenizer.json"
+
+	// the environment variables read by TokenizerConfigFromEnv
+	ENV_TOKENIZER_PATH    = "GO4SEARCH_TOKENIZER_PATH"
+	ENV_TOKENIZER_MODEL   = "GO4SEARCH_TOKENIZER_MODEL"
+	ENV_TOKENIZER_OFFLINE = "GO4SEARCH_TOKENIZER_OFFLINE"
+)
+
+var (
+	ErrTokenizerNotFound  = errors.New("tokenizer file not found")
+	ErrTokenizerNotLoaded = errors.New("tokenizer not loaded")
+)
+
 var tokenizers *tokenizer.Tokenizer
 
-func Init_Tokenizer() {
-	// Download and cache pretrained tokenizer. In this case `bert-base-multilingual-cased` from Huggingface
-	// can be any model with `tokenizer.json` available. E.g. `tiiuae/falcon-7b`
-	configFile, err := tokenizer.CachedPath("bert-base-multilingual-cased", "tokenizer.json")
-	if err != nil {
-		panic(err)
+// TokenizerConfig tells where to load the tokenizer from.
+type TokenizerConfig struct {
+	// Path is either a tokenizer.json file, or a directory holding the tokenizer.json of one or several models,
+	// as <Path>/<Model>/tokenizer.json or <Path>/tokenizer.json. The tokenizer is never downloaded if Path is set.
+	Path string
+	// Model is the name of the pretrained tokenizer, DEFAULT_TOKENIZER_MODEL if empty.
+	Model string
+	// Offline forbids downloading the tokenizer from Hugging Face when it is not in the local cache.
+	Offline bool
+}
+
+/**
+ * Read the tokenizer configuration from the environment:
+ * GO4SEARCH_TOKENIZER_PATH, GO4SEARCH_TOKENIZER_MODEL and GO4SEARCH_TOKENIZER_OFFLINE (e.g. `1` or `true`).
+ *
+ * @return TokenizerConfig
+ */
+func TokenizerConfigFromEnv() TokenizerConfig {
+	offline, _ := strconv.ParseBool(os.Getenv(ENV_TOKENIZER_OFFLINE))
+	return TokenizerConfig{
+		Path:    os.Getenv(ENV_TOKENIZER_PATH),
+		Model:   os.Getenv(ENV_TOKENIZER_MODEL),
+		Offline: offline,
 	}
+}
 
-	tk, err := pretrained.FromFile(configFile)
+/**
+ * Initialize the tokenizer with the configuration read from the environment.
+ * Without configuration, the `bert-base-multilingual-cased` tokenizer is loaded from the local cache,
+ * or downloaded from Hugging Face.
+ *
+ * @return error if the tokenizer cannot be found or loaded
+ */
+func Init_Tokenizer() error {
+	return Load_Tokenizer(TokenizerConfigFromEnv())
+}
+
+/**
+ * Load the tokenizer described by the given configuration, and use it for the subsequent tokenizations.
+ * The current tokenizer is kept if loading fails.
+ *
+ * @param config TokenizerConfig
+ * @return error if the tokenizer cannot be found or loaded
+ */
+func Load_Tokenizer(config TokenizerConfig) error {
+	configFile, err := resolveTokenizerFile(config)
+	if err != nil {
+		return err
+	}
+	tk, err := loadTokenizerFile(configFile)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to load the tokenizer from %s: %w", configFile, err)
 	}
 	tokenizers = tk
+	return nil
+}
+
+// resolveTokenizerFile returns the path of the tokenizer.json to load, downloading it if allowed.
+func resolveTokenizerFile(config TokenizerConfig) (string, error) {
+	model := config.Model
+	if model == "" {
+		model = DEFAULT_TOKENIZER_MODEL
+	}
+
+	if config.Path != "" {
+		info, err := os.Stat(config.Path)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrTokenizerNotFound, err)
+		}
+		if !info.IsDir() {
+			return config.Path, nil
+		}
+		candidates := []string{
+			filepath.Join(config.Path, model, TOKENIZER_FILE),
+			filepath.Join(config.Path, TOKENIZER_FILE),
+		}
+		for _, candidate := range candidates {
+			if isFile(candidate) {
+				return candidate, nil
+			}
+		}
+		return "", fmt.Errorf("%w: no %s for %s in %s", ErrTokenizerNotFound, TOKENIZER_FILE, model, config.Path)
+	}
+
+	cached := filepath.Join(tokenizer.CachedDir, model, TOKENIZER_FILE)
+	if isFile(cached) {
+		return cached, nil
+	}
+	if config.Offline {
+		return "", fmt.Errorf("%w: %s is not in the cache %s and downloading is disabled", ErrTokenizerNotFound, model, tokenizer.CachedDir)
+	}
+
+	configFile, err := tokenizer.CachedPath(model, TOKENIZER_FILE)
+	if err != nil {
+		return "", fmt.Errorf("failed to download the tokenizer %s: %w", model, err)
+	}
+	return configFile, nil
+}
+
+// loadTokenizerFile parses a tokenizer.json, and turns the panics raised on unsupported components into errors.
+func loadTokenizerFile(configFile string) (tk *tokenizer.Tokenizer, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			tk, err = nil, fmt.Errorf("unsupported tokenizer: %v", r)
+		}
+	}()
+	return pretrained.FromFile(configFile)
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
 }
 
-func Tokenize_Query(query string) []string {
+/**
+ * Split a text into the subword tokens of the pretrained tokenizer, see Load_Tokenizer.
+ *
+ * @param query string
+ * @return ([]string, error) the tokens, ErrTokenizerNotLoaded if no tokenizer has been loaded
+ */
+func Tokenize_Query(query string) ([]string, error) {
+	if tokenizers == nil {
+		return nil, ErrTokenizerNotLoaded
+	}
 	en, err := tokenizers.EncodeSingle(query)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to tokenize %q: %w", query, err)
+	}
+	return en.Tokens, nil
+}
+
+// SubwordTokenizer splits a text into the subword tokens of the pretrained tokenizer, see Init_Tokenizer.
+// A text the tokenizer fails to encode yields no tokens and the error is logged, rather than the text being split
+// another way, so that all the terms of an index come from the same tokenization.
+type SubwordTokenizer struct{}
+
+func (SubwordTokenizer) Tokenize(text string) []Token {
+	terms, err := Tokenize_Query(text)
+	if err != nil {
+		log.Println("Error tokenizing the text", err)
+		return []Token{}
+	}
+	return newTokens(terms)
+}
+
+/**
+ * Create the subword tokenizer if a tokenizer has been loaded (see Load_Tokenizer), or a WhitespaceTokenizer
+ * otherwise, so that an analyzer created without a tokenizer still works. The choice is made once, when the
+ * analyzer is created, so that loading a tokenizer afterwards does not change how its index is tokenized.
+ *
+ * @return Tokenizer
+ */
+func NewSubwordTokenizer() Tokenizer {
+	if tokenizers == nil {
+		return WhitespaceTokenizer{}
+	}
+	return SubwordTokenizer{}
+}
+
+// WhitespaceTokenizer splits a text on white spaces.
+type WhitespaceTokenizer struct{}
+
+func (WhitespaceTokenizer) Tokenize(text string) []Token {
+	return newTokens(strings.Fields(text))
+}
+
+// newTokens returns the tokens of the terms, at consecutive positions.
+func newTokens(terms []string) []Token {
+	tokens := make([]Token, len(terms))
+	for i, term := range terms {
+		tokens[i] = Token{Term: term, Position: i}
 	}
-	return en.Tokens
+	return tokens
+}
+
+/**
+ * Tokenize the runs of runes of a script with tokenizeRun, and the rest of the text with the fallback tokenizer.
+ * The positions of the tokens of each run follow the positions of the tokens before it.
+ *
+ * @param text string
+ * @param inScript Whether a rune belongs to the script
+ * @param tokenizeRun The tokenizer of a run of runes of the script
+ * @param fallback The tokenizer of the rest of the text, WhitespaceTokenizer if nil
+ * @return []Token
+ */
+func tokenizeScript(text string, inScript func(rune) bool, tokenizeRun func(run []rune) []Token, fallback Tokenizer) []Token {
+	if fallback == nil {
+		fallback = WhitespaceTokenizer{}
+	}
+
+	tokens := make([]Token, 0)
+	next := 0
+	appendTokens := func(runTokens []Token) {
+		offset := next
+		for _, token := range runTokens {
+			token.Position += offset
+			tokens = append(tokens, token)
+			next = max(next, token.Position+1)
+		}
+	}
+
+	var other strings.Builder
+	run := make([]rune, 0)
+	for _, r := range text + " " {
+		if inScript(r) {
+			run = append(run, r)
+			continue
+		}
+		if len(run) > 0 {
+			// the other runes are tokenized by batches
+			if other.Len() > 0 {
+				appendTokens(fallback.Tokenize(other.String()))
+				other.Reset()
+			}
+			appendTokens(tokenizeRun(run))
+			run = run[:0]
+			// keep the text on both sides of the run apart, e.g. in `IU는(가수)`
+			other.WriteRune(' ')
+		}
+		other.WriteRune(r)
+	}
+	appendTokens(fallback.Tokenize(other.String()))
+
+	return tokens
 }
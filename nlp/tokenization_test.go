@@ -0,0 +1,129 @@
+package nlp
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sugarme/tokenizer"
+)
+
+// testdata/tokenizer.json is a tiny WordPiece tokenizer, so that the tests never download a pretrained one.
+const testTokenizerFile = "testdata/tokenizer.json"
+
+func TestLoadTokenizerFromFile(t *testing.T) {
+	if err := Load_Tokenizer(TokenizerConfig{Path: testTokenizerFile}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tokens, err := Tokenize_Query("hobbits love happiness")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"hobbit", "##s", "love", "happi", "##ness"}
+	if !equalTokens(tokens, expected) {
+		t.Errorf("Expected %v, got %v", expected, tokens)
+	}
+}
+
+func TestLoadTokenizerFromDirectory(t *testing.T) {
+	content, err := os.ReadFile(testTokenizerFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "my-model"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "my-model", TOKENIZER_FILE), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Load_Tokenizer(TokenizerConfig{Path: dir, Model: "my-model"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	// the directory has no tokenizer for the default model, and the tokenizer must not be downloaded
+	if err := Load_Tokenizer(TokenizerConfig{Path: dir}); !errors.Is(err, ErrTokenizerNotFound) {
+		t.Errorf("Expected ErrTokenizerNotFound, got %v", err)
+	}
+}
+
+func TestLoadTokenizerErrors(t *testing.T) {
+	if err := Load_Tokenizer(TokenizerConfig{Path: testTokenizerFile}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := Load_Tokenizer(TokenizerConfig{Path: filepath.Join(t.TempDir(), "missing.json")}); !errors.Is(err, ErrTokenizerNotFound) {
+		t.Errorf("Expected ErrTokenizerNotFound, got %v", err)
+	}
+
+	invalid := filepath.Join(t.TempDir(), TOKENIZER_FILE)
+	if err := os.WriteFile(invalid, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load_Tokenizer(TokenizerConfig{Path: invalid}); err == nil {
+		t.Error("Expected an error for an invalid tokenizer file")
+	}
+
+	cachedDir := tokenizer.CachedDir
+	tokenizer.CachedDir = t.TempDir()
+	defer func() { tokenizer.CachedDir = cachedDir }()
+	if err := Load_Tokenizer(TokenizerConfig{Model: "some-model", Offline: true}); !errors.Is(err, ErrTokenizerNotFound) {
+		t.Errorf("Expected ErrTokenizerNotFound in offline mode, got %v", err)
+	}
+
+	// the previously loaded tokenizer is kept
+	if tokens, _ := Tokenize_Query("the hole"); !equalTokens(tokens, []string{"the", "hole"}) {
+		t.Errorf("Expected the previous tokenizer to be kept, got %v", tokens)
+	}
+}
+
+func TestTokenizerNotLoaded(t *testing.T) {
+	loaded := tokenizers
+	tokenizers = nil
+	defer func() { tokenizers = loaded }()
+
+	if _, err := Tokenize_Query("hobbits love happiness"); !errors.Is(err, ErrTokenizerNotLoaded) {
+		t.Errorf("Expected ErrTokenizerNotLoaded, got %v", err)
+	}
+	// the subword tokenizer does not split the text another way
+	if terms := Terms(SubwordTokenizer{}.Tokenize("hobbits love happiness")); len(terms) != 0 {
+		t.Errorf("Expected no tokens, got %v", terms)
+	}
+	// the analyzers created without a tokenizer split the text on white spaces, even once a tokenizer is loaded
+	if _, ok := NewSubwordTokenizer().(WhitespaceTokenizer); !ok {
+		t.Errorf("Expected the white space tokenizer, got %T", NewSubwordTokenizer())
+	}
+	analyzer := NewSubwordAnalyzer()
+	tokenizers = loaded
+	if terms := Terms(analyzer.AnalyzeLanguage("hobbits", "en")); !equalTokens(terms, []string{"hobbits"}) {
+		t.Errorf("Expected the analyzer to keep splitting on white spaces, got %v", terms)
+	}
+	tokenizers = nil
+	if terms := Terms(NewStandardAnalyzer().Analyze("The hobbits")); !equalTokens(terms, []string{"hobbit"}) {
+		t.Errorf("Expected the standard analyzer to work without a tokenizer, got %v", terms)
+	}
+}
+
+func TestTokenizerConfigFromEnv(t *testing.T) {
+	t.Setenv(ENV_TOKENIZER_PATH, "/models")
+	t.Setenv(ENV_TOKENIZER_MODEL, "bert-base-uncased")
+	t.Setenv(ENV_TOKENIZER_OFFLINE, "true")
+
+	config := TokenizerConfigFromEnv()
+	if config.Path != "/models" || config.Model != "bert-base-uncased" || !config.Offline {
+		t.Errorf("Unexpected configuration %+v", config)
+	}
+}
+
+func equalTokens(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,93 @@
 package nlp
 
-func Calculate_Levenshtein_Distance(tokens []string) []string {
-	//TODO <https://github.com/texttheater/golang-levenshtein>
-	return tokens
+/**
+ * Calculate the Levenshtein distance (the minimum number of rune insertions, deletions and substitutions)
+ * required to turn the source string into the target string.
+ *
+ * @param source string
+ * @param target string
+ * @return int distance
+ */
+func Calculate_Levenshtein_Distance(source string, target string) int {
+	s := []rune(source)
+	t := []rune(target)
+
+	row := make([]int, len(t)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			next := min(row[j]+1, row[j-1]+1, diagonal+cost)
+			diagonal = row[j]
+			row[j] = next
+		}
+	}
+	return row[len(t)]
+}
+
+// LevenshteinState is a state of a LevenshteinAutomaton.
+// It holds the edit distance between every prefix of the automaton word and the input consumed so far,
+// capped at maxDistance+1.
+type LevenshteinState []int
+
+// LevenshteinAutomaton accepts the strings that are within a maximum edit distance of a word.
+// The input is consumed one rune at a time, so the automaton can be run along the paths of a sorted
+// term dictionary or a trie, and whole branches can be pruned as soon as the state cannot match anymore.
+type LevenshteinAutomaton struct {
+	word        []rune
+	maxDistance int
+}
+
+func NewLevenshteinAutomaton(word string, maxDistance int) *LevenshteinAutomaton {
+	return &LevenshteinAutomaton{word: []rune(word), maxDistance: maxDistance}
+}
+
+// Start returns the state of the automaton before any input has been consumed.
+func (a *LevenshteinAutomaton) Start() LevenshteinState {
+	state := make(LevenshteinState, len(a.word)+1)
+	for i := range state {
+		state[i] = min(i, a.maxDistance+1)
+	}
+	return state
+}
+
+// Step returns the state reached from the given state after consuming the rune r.
+func (a *LevenshteinAutomaton) Step(state LevenshteinState, r rune) LevenshteinState {
+	next := make(LevenshteinState, len(state))
+	next[0] = min(state[0]+1, a.maxDistance+1)
+	for i := 1; i < len(state); i++ {
+		cost := 1
+		if a.word[i-1] == r {
+			cost = 0
+		}
+		next[i] = min(state[i]+1, next[i-1]+1, state[i-1]+cost, a.maxDistance+1)
+	}
+	return next
+}
+
+// IsMatch reports whether the input consumed so far is within the maximum edit distance of the word.
+func (a *LevenshteinAutomaton) IsMatch(state LevenshteinState) bool {
+	return state[len(state)-1] <= a.maxDistance
+}
+
+// CanMatch reports whether some continuation of the input consumed so far can still match the word.
+func (a *LevenshteinAutomaton) CanMatch(state LevenshteinState) bool {
+	for _, distance := range state {
+		if distance <= a.maxDistance {
+			return true
+		}
+	}
+	return false
+}
+
+// Distance returns the edit distance between the word and the input consumed so far.
+func (a *LevenshteinAutomaton) Distance(state LevenshteinState) int {
+	return state[len(state)-1]
 }
@@ -0,0 +1,45 @@
+package nlp
+
+import (
+	"testing"
+)
+
+func TestCalculateLevenshteinDistance(t *testing.T) {
+	testCases := []struct {
+		source, target string
+		expected       int
+	}{
+		{"", "", 0},
+		{"hobbit", "hobbit", 0},
+		{"hobit", "hobbit", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+		{"아이유", "아이요", 1},
+	}
+	for _, tc := range testCases {
+		if distance := Calculate_Levenshtein_Distance(tc.source, tc.target); distance != tc.expected {
+			t.Errorf("Distance between %q and %q: expected %d, got %d", tc.source, tc.target, tc.expected, distance)
+		}
+	}
+}
+
+func TestLevenshteinAutomaton(t *testing.T) {
+	words := []string{"dream", "dreams", "drum", "cream", "dr", "random", ""}
+	for maxDistance := 0; maxDistance <= 2; maxDistance++ {
+		automaton := NewLevenshteinAutomaton("dream", maxDistance)
+		for _, word := range words {
+			state := automaton.Start()
+			for _, r := range word {
+				state = automaton.Step(state, r)
+			}
+
+			distance := Calculate_Levenshtein_Distance("dream", word)
+			if automaton.IsMatch(state) != (distance <= maxDistance) {
+				t.Errorf("IsMatch(%q) with max distance %d: expected %v", word, maxDistance, distance <= maxDistance)
+			}
+			if automaton.IsMatch(state) && automaton.Distance(state) != distance {
+				t.Errorf("Distance(%q): expected %d, got %d", word, distance, automaton.Distance(state))
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package nlp
+
+import (
+	"strings"
+)
+
+// WORDPIECE_PREFIX marks the WordPiece tokens continuing the previous token, e.g. `##s` in `hobbit ##s`.
+const WORDPIECE_PREFIX = "##"
+
+// specialTokens are the special tokens of the pretrained tokenizers, which are not words of the text.
+var specialTokens = map[string]bool{
+	"[CLS]":  true,
+	"[SEP]":  true,
+	"[UNK]":  true,
+	"[PAD]":  true,
+	"[MASK]": true,
+	"<s>":    true,
+	"</s>":   true,
+	"<unk>":  true,
+	"<pad>":  true,
+	"<mask>": true,
+}
+
+// IsSpecialToken returns whether a token is a special token of the pretrained tokenizers, e.g. `[CLS]` or `[UNK]`.
+func IsSpecialToken(term string) bool {
+	return specialTokens[term]
+}
+
+// SpecialTokenFilter is a token filter removing the special tokens of the pretrained tokenizers,
+// e.g. `[CLS]`, `[SEP]` and the `[UNK]` standing for the words missing from the vocabulary.
+type SpecialTokenFilter struct{}
+
+func (SpecialTokenFilter) Filter(tokens []Token) []Token {
+	filtered := make([]Token, 0, len(tokens))
+	for _, token := range tokens {
+		if !IsSpecialToken(token.Term) {
+			filtered = append(filtered, token)
+		}
+	}
+	return filtered
+}
+
+// WordPieceMergeFilter is a token filter merging the WordPiece tokens back into whole words (e.g. `hobbit ##s`
+// into `hobbits`), so that a word does not match the documents merely sharing a piece of it.
+// The positions of the following tokens are shifted, so that the words are at consecutive positions.
+type WordPieceMergeFilter struct{}
+
+func (WordPieceMergeFilter) Filter(tokens []Token) []Token {
+	merged := make([]Token, 0, len(tokens))
+	shift := 0
+	for _, token := range tokens {
+		piece, isPiece := strings.CutPrefix(token.Term, WORDPIECE_PREFIX)
+		if isPiece && piece != "" {
+			last := len(merged) - 1
+			if last >= 0 && !IsSpecialToken(merged[last].Term) {
+				merged[last].Term += piece
+				shift++
+				continue
+			}
+			token.Term = piece
+		}
+		token.Position -= shift
+		merged = append(merged, token)
+	}
+	return merged
+}
+
+/**
+ * Create an analyzer indexing the subword pieces of the pretrained tokenizer (see Init_Tokenizer) as they are,
+ * e.g. `hobbit` and `##s` for `hobbits`, without the special tokens. It is meant for a secondary field, where the
+ * words sharing pieces match each other.
+ *
+ * @return *Analyzer
+ */
+func NewSubwordAnalyzer() *Analyzer {
+	return NewAnalyzer(NormalizationFilters(false), NewSubwordTokenizer(), SpecialTokenFilter{}, NewStopwordFilter(""))
+}
@@ -0,0 +1,37 @@
+package nlp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWordPieceMergeFilter(t *testing.T) {
+	tokens := newTokens([]string{"hobbit", "##s", "love", "happi", "##ness", "[UNK]", "##s", "the"})
+	expected := []Token{{"hobbits", 0}, {"love", 1}, {"happiness", 2}, {"[UNK]", 3}, {"s", 4}, {"the", 5}}
+	if merged := (WordPieceMergeFilter{}).Filter(tokens); !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Expected %v, got %v", expected, merged)
+	}
+}
+
+func TestSpecialTokenFilter(t *testing.T) {
+	tokens := newTokens([]string{"[CLS]", "hobbit", "[UNK]", "love", "[SEP]"})
+	expected := []Token{{"hobbit", 1}, {"love", 3}}
+	if filtered := (SpecialTokenFilter{}).Filter(tokens); !reflect.DeepEqual(filtered, expected) {
+		t.Errorf("Expected %v, got %v", expected, filtered)
+	}
+}
+
+func TestStandardAnalyzerWordPieces(t *testing.T) {
+	if err := Load_Tokenizer(TokenizerConfig{Path: testTokenizerFile}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// the pieces are merged before stemming, and the unknown words are dropped
+	if terms := Terms(NewStandardAnalyzer().AnalyzeLanguage("hobbits love xyz happiness", "en")); !reflect.DeepEqual(terms, []string{"hobbit", "love", "happi"}) {
+		t.Errorf("Expected the stems of the whole words, got %v", terms)
+	}
+	// while the subword analyzer keeps the pieces
+	if terms := Terms(NewSubwordAnalyzer().AnalyzeLanguage("hobbits love xyz happiness", "en")); !reflect.DeepEqual(terms, []string{"hobbit", "##s", "love", "happi", "##ness"}) {
+		t.Errorf("Expected the subword pieces, got %v", terms)
+	}
+}
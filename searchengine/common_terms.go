@@ -0,0 +1,139 @@
+package searchengine
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	documents "go4search/documents"
+)
+
+// DEFAULT_COMMON_TERMS_CUTOFF is the default ratio of the documents above which a term is common,
+// see CommonTermsSearch and StopwordCandidates.
+const DEFAULT_COMMON_TERMS_CUTOFF = 0.1
+
+// ErrStopwordsNotIndexed is returned by CommonTermsSearch when the content analyzer removes the stopwords and the
+// search engine has no unstemmed index keeping them, e.g. when it was not created with NewSearchEngine.
+var ErrStopwordsNotIndexed = errors.New("the stopwords are not indexed")
+
+// StopwordCandidate is an index term found in many documents, which could be used as a stopword.
+type StopwordCandidate struct {
+	Term              string
+	DocumentFrequency int     // the number of documents containing the term
+	Ratio             float64 // the ratio of the documents containing the term
+}
+
+// documentFrequency returns the number of documents containing a term, from the postings of the term.
+func documentFrequency(postings []int) int {
+	return len(termFrequencies(postings))
+}
+
+// isCommonTerm returns whether a query term is found in at least the given ratio of the documents.
+func (se *SearchEngine) isCommonTerm(term queryTerm, cutoff float64) bool {
+	frequency := documentFrequency(se.fieldIndex(term.field)[term.token])
+	return len(se.Documents) > 0 && float64(frequency) >= cutoff*float64(len(se.Documents))
+}
+
+/**
+ * Return the words found in at least the given ratio of the documents, the most frequent first.
+ * They are the stopwords of the corpus rather than of a language, and can be reviewed and then removed at index time
+ * with nlp.StopwordConfig. The words are counted as they are written, with the stopwords (see FIELD_UNSTEMMED),
+ * so that they can be given to nlp.StopwordConfig as they are.
+ *
+ * @param cutoff The minimum ratio of the documents containing a term, between 0 and 1, e.g. DEFAULT_COMMON_TERMS_CUTOFF
+ * @param limit The maximum number of candidates to return, all of them if limit <= 0
+ * @return []StopwordCandidate
+ */
+func (se *SearchEngine) StopwordCandidates(cutoff float64, limit int) []StopwordCandidate {
+	candidates := make([]StopwordCandidate, 0)
+	if len(se.Documents) == 0 {
+		return candidates
+	}
+	index := se.fieldIndex(se.vocabularyField())
+	for term := range index {
+		frequency := documentFrequency(index[term])
+		ratio := float64(frequency) / float64(len(se.Documents))
+		if ratio >= cutoff {
+			candidates = append(candidates, StopwordCandidate{Term: term, DocumentFrequency: frequency, Ratio: ratio})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].DocumentFrequency != candidates[j].DocumentFrequency {
+			return candidates[i].DocumentFrequency > candidates[j].DocumentFrequency
+		}
+		return candidates[i].Term < candidates[j].Term
+	})
+	if limit > 0 && len(candidates) > limit {
+		return candidates[:limit]
+	}
+	return candidates
+}
+
+/**
+ * Search for documents as SearchQuery, with the common terms of the query only refining the results of its rare terms.
+ * The query terms found in at least cutoff of the documents (e.g. `to`, `be`, `not` when the stopwords are indexed)
+ * only add to the scores of the documents matching a rare term, so that they rank these documents without flooding
+ * the results with the documents matching a common term only. A query made of common terms only
+ * (e.g. `to be or not to be`) matches the documents containing all of them.
+ * The words of the query are matched as they are written, with the stopwords (see FIELD_UNSTEMMED), so that this mode
+ * works whether or not the content analyzer removes the stopwords.
+ *
+ * @param query A search query
+ * @param cutoff The minimum ratio of the documents containing a common term, between 0 and 1, e.g. DEFAULT_COMMON_TERMS_CUTOFF
+ * @param limit The maximum number of results to return
+ *
+ * @return ([]documents.Document, error) the results, ErrInvalidQuery if the query syntax is invalid or the cutoff
+ *         is not between 0 and 1, ErrPatternTooBroad if a pattern matches more than MAX_EXPANSIONS terms,
+ *         ErrStopwordsNotIndexed if the stopwords are not indexed in any field
+ */
+func (se *SearchEngine) CommonTermsSearch(query string, cutoff float64, limit int) ([]documents.Document, error) {
+	if cutoff <= 0 || cutoff > 1 {
+		return nil, fmt.Errorf("%w: the common terms cutoff %v must be in ]0, 1]", ErrInvalidQuery, cutoff)
+	}
+	if se.Analyzer(FIELD_CONTENT).RemovesStopwords() && se.Unstemmed == nil {
+		return nil, fmt.Errorf("%w: the common terms search needs an index with the stopwords", ErrStopwordsNotIndexed)
+	}
+	return se.searchWithCorrection(query, limit, searchOptions{commonTermsCutoff: cutoff})
+}
+
+/**
+ * Score the query terms, the common terms only adding to the scores of the documents matching the rare terms.
+ * If all the terms are common, the documents containing all of them are scored.
+ *
+ * @param terms The query terms
+ * @param cutoff The minimum ratio of the documents containing a common term
+ * @return map[int]float64 the score of each document
+ */
+func (se *SearchEngine) scoreCommonTerms(terms []queryTerm, cutoff float64) map[int]float64 {
+	rare := make([]queryTerm, 0, len(terms))
+	common := make([]queryTerm, 0, len(terms))
+	for _, term := range terms {
+		if se.isCommonTerm(term, cutoff) {
+			common = append(common, term)
+		} else {
+			rare = append(rare, term)
+		}
+	}
+
+	if len(rare) == 0 {
+		scores := se.scoreTerms(common)
+		for _, term := range common {
+			frequencies := termFrequencies(se.fieldIndex(term.field)[term.token])
+			for docID := range scores {
+				if _, ok := frequencies[docID]; !ok {
+					delete(scores, docID)
+				}
+			}
+		}
+		return scores
+	}
+
+	scores := se.scoreTerms(rare)
+	for docID, score := range se.scoreTerms(common) {
+		if _, ok := scores[docID]; ok {
+			scores[docID] += score
+		}
+	}
+	return scores
+}
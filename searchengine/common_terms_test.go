@@ -0,0 +1,139 @@
+package searchengine
+
+import (
+	"errors"
+	"testing"
+
+	nlp "go4search/nlp"
+)
+
+// commonTermsTestEngine indexes the stopwords, as the common terms query mode is meant for
+var commonTermsTestEngine = testEngine{
+	analyzer: nlp.NewWhitespaceAnalyzer(),
+	contents: []string{
+		"To be or not to be that is the question",
+		"The hobbit is not in the hole",
+		"To sleep perchance to dream",
+		"The hobbit went to the ground",
+		"It is the best of times",
+		"The sky above the port",
+	},
+}
+
+func TestStopwordCandidates(t *testing.T) {
+	se := commonTermsTestEngine.build()
+
+	candidates := se.StopwordCandidates(0.2, 0)
+	expected := []StopwordCandidate{{"the", 5, 5.0 / 12}, {"is", 3, 3.0 / 12}, {"to", 3, 3.0 / 12}}
+	if len(candidates) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, candidates)
+	}
+	for i := range expected {
+		if candidates[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], candidates[i])
+		}
+	}
+	if candidates := se.StopwordCandidates(0.2, 1); len(candidates) != 1 || candidates[0].Term != "the" {
+		t.Errorf("Expected the most frequent term only, got %v", candidates)
+	}
+
+	// the candidates can be removed at index time
+	terms := make([]string, 0)
+	for _, candidate := range candidates {
+		terms = append(terms, candidate.Term)
+	}
+	if err := se.SetStopwords(nlp.StopwordConfig{Words: terms}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := se.Index["the"]; ok {
+		t.Error("Expected the candidates not to be indexed")
+	}
+	// the words are still counted as they are written, and still match as common terms
+	if candidates := se.StopwordCandidates(0.2, 0); len(candidates) != len(expected) {
+		t.Errorf("Expected %v, got %v", expected, candidates)
+	}
+	results, err := se.CommonTermsSearch("the hobbit", 0.3, 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[0].ID+results[1].ID != 4 {
+		t.Errorf("Expected documents 1 and 3, got %v", results)
+	}
+}
+
+func TestCommonTermsSearch(t *testing.T) {
+	se := commonTermsTestEngine.build()
+
+	// `the` matches documents on its own in a regular search
+	if results := se.Search("the hobbit", 10); len(results) != 5 {
+		t.Errorf("Expected documents 0, 1, 3, 4 and 5, got %v", results)
+	}
+	// while the common term only ranks the documents matching `hobbit`
+	results, err := se.CommonTermsSearch("the hobbit", 0.3, 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[0].ID+results[1].ID != 4 {
+		t.Errorf("Expected documents 1 and 3, got %v", results)
+	}
+
+	// a query made of common terms only matches the documents containing all of them
+	results, err = se.CommonTermsSearch("to be or not to be", 0.1, 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != 0 {
+		t.Errorf("Expected document 0, got %v", results)
+	}
+
+	if _, err := se.CommonTermsSearch("the hobbit", 0, 10); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("Expected ErrInvalidQuery, got %v", err)
+	}
+}
+
+func TestCommonTermsSearchDefaultConfiguration(t *testing.T) {
+	if err := nlp.Load_Tokenizer(nlp.TokenizerConfig{Path: "../nlp/testdata/tokenizer.json"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, env := range []string{nlp.ENV_STOPWORDS, nlp.ENV_STOPWORDS_FILE, nlp.ENV_PHRASE_STOPWORDS} {
+		t.Setenv(env, "")
+	}
+	// the search engine of main: the standard analyzer removing the stopwords of the environment
+	config := nlp.StopwordConfigFromEnv()
+	filter, err := config.Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := testEngine{
+		analyzer: nlp.NewStandardAnalyzer().WithStopwords(filter),
+		options:  EngineOptions{KeepPhraseStopwords: config.KeepInPhrases},
+		contents: []string{"In a hole in the ground", "The hobbit hole in the ground", "The dream", "A hobbit in love"},
+	}
+	se := engine.build()
+	if _, ok := se.Index["the"]; ok {
+		t.Fatal("Expected the stopwords not to be indexed")
+	}
+
+	// the common terms are matched with their stopwords
+	results, err := se.CommonTermsSearch("in the", DEFAULT_COMMON_TERMS_CUTOFF, 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[0].ID+results[1].ID != 1 {
+		t.Errorf("Expected documents 0 and 1, got %v", results)
+	}
+	results, err = se.CommonTermsSearch("the hobbit", 0.3, 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != 1 || results[1].ID != 3 {
+		t.Errorf("Expected documents 1 and 3, got %v", results)
+	}
+
+	// and the candidates are the words as they are written, with the stopwords
+	candidates := se.StopwordCandidates(0.3, 0)
+	expected := []StopwordCandidate{{"in", 3, 0.3}, {"the", 3, 0.3}}
+	if len(candidates) != len(expected) || candidates[0] != expected[0] || candidates[1] != expected[1] {
+		t.Errorf("Expected %v, got %v", expected, candidates)
+	}
+}
@@ -0,0 +1,124 @@
+package searchengine
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	nlp "go4search/nlp"
+)
+
+// FIELD_SUBWORD is a secondary field indexing the subword pieces of the content (see nlp.NewSubwordAnalyzer),
+// so that the words sharing pieces match each other, e.g. `hobbits` and `hobbit`.
+const FIELD_SUBWORD = "content.subword"
+
+// FIELD_UNSTEMMED is the content indexed without stemming and with the stopwords when the content analyzer stems or
+// removes stopwords (see SearchEngine.Unstemmed), so that the wildcard patterns, the fuzzy terms and the spelling
+// suggestions match the words as they are written, e.g. `happines*` matches `happiness` although it is indexed as
+// `happi`, and so that the common terms searches and the stopword candidates see the stopwords.
+const FIELD_UNSTEMMED = "content.unstemmed"
+
+// SUBWORD_FIELD_WEIGHT is the weight of the matches in FIELD_SUBWORD, lower than the whole word matches of the content.
+const SUBWORD_FIELD_WEIGHT = 0.5
+
+// ENV_SUBWORD_FIELD also indexes FIELD_SUBWORD when set to `1` or `true`, so that the words sharing pieces match.
+const ENV_SUBWORD_FIELD = "GO4SEARCH_SUBWORD_FIELD"
+
+/**
+ * Read from the environment whether to index FIELD_SUBWORD: GO4SEARCH_SUBWORD_FIELD (e.g. `1` or `true`).
+ *
+ * @return bool
+ */
+func SubwordFieldFromEnv() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(ENV_SUBWORD_FIELD))
+	return enabled
+}
+
+// FieldConfig describes a secondary field of EngineOptions, see AddField.
+type FieldConfig struct {
+	Name     string        // the name of the field, e.g. FIELD_SUBWORD
+	Analyzer *nlp.Analyzer // the analyzer of the field
+	Weight   float64       // the weight of the matches in the field, e.g. SUBWORD_FIELD_WEIGHT
+}
+
+/**
+ * Index the content of the documents in a secondary field, analyzed with another analyzer (e.g. FIELD_SUBWORD with
+ * nlp.NewSubwordAnalyzer()). The queries are analyzed with the same analyzer, and their matches in the field add to
+ * the document scores with the given weight.
+ *
+ * @param field The name of the field, e.g. FIELD_SUBWORD
+ * @param analyzer The analyzer of the field
+ * @param weight The weight of the matches in the field, e.g. SUBWORD_FIELD_WEIGHT
+ */
+func (se *SearchEngine) AddField(field string, analyzer *nlp.Analyzer, weight float64) {
+	if se.FieldIndexes == nil {
+		se.FieldIndexes = make(map[string]InvertedIndex)
+		se.FieldWeights = make(map[string]float64)
+	}
+	se.SetAnalyzer(field, analyzer)
+	se.indexField(field, analyzer)
+	se.FieldWeights[field] = weight
+}
+
+// fieldIndex returns the index of a field, the content index for the empty field.
+func (se *SearchEngine) fieldIndex(field string) InvertedIndex {
+	switch field {
+	case "", FIELD_CONTENT:
+		return se.Index
+	case FIELD_UNSTEMMED:
+		// the content index holds the words as they are written when it is not stemmed
+		if se.Unstemmed == nil {
+			return se.Index
+		}
+		return se.Unstemmed
+	}
+	return se.FieldIndexes[field]
+}
+
+// indexField builds the index of a secondary field, without a Bloom filter, and the lengths of the documents in it.
+func (se *SearchEngine) indexField(field string, analyzer *nlp.Analyzer) {
+	index := make(InvertedIndex)
+	se.resetFieldLengths(field)
+	for _, doc := range se.Documents {
+		tokens := analyzer.AnalyzeLanguage(doc.Content, doc.Language)
+		addPostings(index, doc.ID, tokens, nil)
+		se.addFieldLength(field, len(tokens))
+	}
+	se.FieldIndexes[field] = index
+}
+
+/**
+ * Analyze the free text and the phrases of a query with the analyzer of each secondary field.
+ * FIELD_PHONETIC is only matched on demand, see PhoneticSearch.
+ *
+ * @param parsed The parsed query
+ * @param phonetic Whether the query is matched in FIELD_PHONETIC
+ * @return []queryTerm the terms found in the index of their field, weighted with the weight of the field
+ */
+func (se *SearchEngine) fieldQueryTerms(parsed parsedQuery, phonetic bool) []queryTerm {
+	text := strings.TrimSpace(parsed.text + " " + strings.Join(parsed.phrases, " "))
+	if text == "" {
+		return []queryTerm{}
+	}
+
+	fields := make([]string, 0, len(se.FieldIndexes))
+	for field := range se.FieldIndexes {
+		if field == FIELD_PHONETIC && !phonetic {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	terms := make([]queryTerm, 0)
+	for _, field := range fields {
+		index := se.FieldIndexes[field]
+		for _, term := range se.analyzeQueryText(se.Analyzer(field), text, parsed.languages) {
+			if _, ok := index[term]; ok {
+				terms = append(terms, queryTerm{token: term, weight: se.FieldWeights[field], field: field})
+			}
+		}
+	}
+	return terms
+}
@@ -0,0 +1,100 @@
+package searchengine
+
+import (
+	"math"
+	"testing"
+
+	documents "go4search/documents"
+	nlp "go4search/nlp"
+)
+
+func TestSearchSubwordField(t *testing.T) {
+	// the tiny WordPiece tokenizer of the nlp tests, so that the tests never download a pretrained one
+	if err := nlp.Load_Tokenizer(nlp.TokenizerConfig{Path: "../nlp/testdata/tokenizer.json"}); err != nil {
+		t.Fatal(err)
+	}
+	docs := paddedCorpus("hobbits love happiness", "the dreamness of the hole", "a hobbit in the ground", "xyz [CLS] love", "the ground", "a dream")
+	for i := range docs {
+		docs[i].Language = "en"
+	}
+	analyzer := nlp.NewAnalyzer(nlp.NormalizationFilters(false), nlp.SubwordTokenizer{}, nlp.WordPieceMergeFilter{}, nlp.SpecialTokenFilter{}, nlp.NewStopwordFilter(""))
+	se := NewSearchEngine(docs, map[string]*nlp.Analyzer{FIELD_CONTENT: analyzer})
+
+	// the pieces are indexed as whole words, without the special tokens
+	for _, term := range []string{"##s", "##ness", "[UNK]", "[CLS]"} {
+		if _, ok := se.Index[term]; ok {
+			t.Errorf("Expected %s not to be indexed", term)
+		}
+	}
+	if !equalSlice(se.Index["hobbits"], []int{0}) || !equalSlice(se.Index["hobbit"], []int{2}) {
+		t.Errorf("Expected the whole words to be indexed, got %v", se.Index)
+	}
+	if results := se.Search("hobbit", 10); len(results) != 1 || results[0].ID != 2 {
+		t.Errorf("Expected document 2, got %v", results)
+	}
+
+	// the pieces of the secondary field match the words sharing them, below the whole word matches
+	se.AddField(FIELD_SUBWORD, nlp.NewSubwordAnalyzer(), SUBWORD_FIELD_WEIGHT)
+	if !equalSlice(se.FieldIndexes[FIELD_SUBWORD]["##ness"], []int{0, 1}) {
+		t.Errorf("Expected the pieces to be indexed in the subword field, got %v", se.FieldIndexes[FIELD_SUBWORD])
+	}
+	results := se.Search("hobbit", 10)
+	if len(results) == 0 || results[0].ID != 2 {
+		t.Errorf("Expected document 2 first, got %v", results)
+	}
+	// the BM25 scores of the field compare the lengths of the documents in the field, not in the content
+	if lengths := se.FieldDocLengths[FIELD_SUBWORD]; !equalSlice(lengths[:3], []int{5, 3, 2}) || len(lengths) != len(docs) {
+		t.Errorf("Expected the subword lengths [5 3 2 ...], got %v", lengths)
+	}
+	if average := se.FieldAvgLengths[FIELD_SUBWORD]; math.Abs(average-14.0/12) > 1e-9 {
+		t.Errorf("Expected the average subword length 14/12, got %v", average)
+	}
+	term := queryTerm{token: "hobbit", weight: 1.0, field: FIELD_SUBWORD}
+	tf, dl, avgdl := 1.0, 5.0, 14.0/12
+	idf := math.Log(float64(len(docs)-2)+0.5) / (2 + 0.5)
+	norm := tf + se.K1*(1-se.B+se.B*dl/avgdl)
+	if expected, score := idf*(se.K1+1)*tf*(se.K1+1)/norm/norm*BM25_WEIGHT, se.calculateBM25Score([]queryTerm{term})[0]; math.Abs(score-expected) > 1e-9 {
+		t.Errorf("Expected the BM25 score %v of document 0 in the subword field, got %v", expected, score)
+	}
+	results = se.Search("happiness", 10)
+	if len(results) != 2 || results[0].ID != 0 || results[1].ID != 1 {
+		t.Errorf("Expected documents 0 and 1, got %v", results)
+	}
+
+	// the documents added later are indexed in the secondary field too
+	se.AddNewDocument(documents.Document{ID: len(docs), Content: "the hobbits", Language: "en"})
+	if !equalSlice(se.FieldIndexes[FIELD_SUBWORD]["hobbit"], []int{0, 2, len(docs)}) {
+		t.Errorf("Expected the added document in the subword field, got %v", se.FieldIndexes[FIELD_SUBWORD]["hobbit"])
+	}
+	if lengths := se.FieldDocLengths[FIELD_SUBWORD]; len(lengths) != len(docs)+1 || lengths[len(docs)] != 2 {
+		t.Errorf("Expected the length 2 of the added document in the subword field, got %v", lengths)
+	}
+}
+
+func TestSubwordFieldFromEnv(t *testing.T) {
+	t.Setenv(ENV_SUBWORD_FIELD, "true")
+	if !SubwordFieldFromEnv() {
+		t.Error("Expected the subword field to be enabled")
+	}
+	t.Setenv(ENV_SUBWORD_FIELD, "")
+	if SubwordFieldFromEnv() {
+		t.Error("Expected the subword field to be disabled")
+	}
+}
+
+func TestEngineOptionsFields(t *testing.T) {
+	engine := testEngine{
+		analyzer: nlp.NewWhitespaceAnalyzer(),
+		options:  EngineOptions{Fields: []FieldConfig{{Name: "content.stopwords", Analyzer: newTestAnalyzer(), Weight: 0.5}}},
+		contents: []string{"the hobbit of the shire", "the ground"},
+	}
+	se := engine.build()
+
+	// the secondary fields are indexed with the content, with their own lengths
+	if !equalSlice(se.FieldIndexes["content.stopwords"]["hobbit"], []int{0}) || se.FieldWeights["content.stopwords"] != 0.5 {
+		t.Errorf("Expected the field to be indexed, got %v", se.FieldIndexes["content.stopwords"])
+	}
+	if lengths := se.FieldDocLengths["content.stopwords"]; !equalSlice(lengths[:2], []int{2, 1}) || !equalSlice(se.DocLengths[:2], []int{5, 2}) {
+		t.Errorf("Expected the field lengths [2 1 ...] and the content lengths [5 2 ...], got %v and %v", lengths, se.DocLengths)
+	}
+}
@@ -0,0 +1,65 @@
+package fmindex
+
+import (
+	"math/bits"
+)
+
+// wordsPerBlock is the number of 64-bit words between two precomputed ranks.
+const wordsPerBlock = 8
+
+// bitVector is a fixed-size bit array answering rank queries in constant time,
+// with a precomputed rank every 512 bits (about 6% of space overhead).
+type bitVector struct {
+	words  []uint64
+	blocks []uint32 // blocks[b] is the number of ones before the word b*wordsPerBlock
+}
+
+func newBitVector(n int) *bitVector {
+	return &bitVector{words: make([]uint64, (n+63)/64)}
+}
+
+func (bv *bitVector) set(i int) {
+	bv.words[i/64] |= 1 << (i % 64)
+}
+
+func (bv *bitVector) get(i int) bool {
+	return bv.words[i/64]&(1<<(i%64)) != 0
+}
+
+// build precomputes the ranks, it must be called once all the bits are set.
+func (bv *bitVector) build() {
+	bv.blocks = make([]uint32, len(bv.words)/wordsPerBlock+1)
+	var ones uint32
+	for w, word := range bv.words {
+		if w%wordsPerBlock == 0 {
+			bv.blocks[w/wordsPerBlock] = ones
+		}
+		ones += uint32(bits.OnesCount64(word))
+	}
+	if len(bv.words)%wordsPerBlock == 0 {
+		// the rank of the position one past the last word
+		bv.blocks[len(bv.blocks)-1] = ones
+	}
+}
+
+// rank1 returns the number of ones before position i.
+func (bv *bitVector) rank1(i int) int {
+	w := i / 64
+	ones := int(bv.blocks[w/wordsPerBlock])
+	for k := w / wordsPerBlock * wordsPerBlock; k < w; k++ {
+		ones += bits.OnesCount64(bv.words[k])
+	}
+	if i%64 != 0 {
+		ones += bits.OnesCount64(bv.words[w] & (1<<(i%64) - 1))
+	}
+	return ones
+}
+
+// rank0 returns the number of zeros before position i.
+func (bv *bitVector) rank0(i int) int {
+	return i - bv.rank1(i)
+}
+
+func (bv *bitVector) sizeInBytes() int {
+	return len(bv.words)*8 + len(bv.blocks)*4
+}
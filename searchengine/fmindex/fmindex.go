@@ -0,0 +1,271 @@
+package fmindex
+
+import (
+	"sort"
+	"sync"
+
+	suffixarray "go4search/searchengine/suffixarray"
+)
+
+const (
+	sentinel  = 0 // terminates the text, smaller than any other symbol
+	separator = 1 // terminates each key, so that no match spans two keys
+	firstRune = 2 // the symbol of the smallest rune of the alphabet
+)
+
+// SAMPLE_RATE is the distance between two text positions whose suffix array value is kept to locate the matches.
+// Locating a match walks back at most SAMPLE_RATE-1 positions of the text.
+const SAMPLE_RATE = 32
+
+// FMIndex is a self-index over a set of keys associated with indexes, answering the same queries as the
+// generalized suffix tree and the suffix array.
+//
+// Instead of the text and its suffix array, it keeps the Burrows-Wheeler transform of the text in a wavelet matrix,
+// in ceil(log2(alphabet size)) bits per rune, and one suffix array value every SAMPLE_RATE text positions. The
+// transform is stored with plain bit vectors, so the size depends on the alphabet and the number of runes, not on
+// how repetitive the text is.
+// The occurrences of a word are counted by backward search, and located by walking back to a sampled position with
+// the LF-mapping. The keys are not stored: they are recovered from the transform when more keys are added.
+//
+// The index is static: Put marks it dirty, and the next search rebuilds the whole text once, however many keys
+// were put in between. Put the keys in batches rather than interleaving Put and Search. It is safe for concurrent use.
+type FMIndex struct {
+	mu    sync.RWMutex
+	dirty bool // whether keys have been put since the last build
+
+	pending []int32 // the runes of the keys added since the last build, each key followed by a separator
+	length  int     // the number of runes of the text, including the pending ones
+	starts  []int32 // starts[k] is the position of the k-th key in the text
+	indexes []int   // indexes[k] is the index associated with the k-th key
+
+	alphabet []rune         // the sorted distinct runes of the text, alphabet[i] is the symbol firstRune+i
+	bwt      *waveletMatrix // the Burrows-Wheeler transform of the encoded text
+	counts   []int          // counts[c] is the number of symbols smaller than c in the text
+	sampled  *bitVector     // the rows of the suffix array whose value is sampled
+	samples  []int32        // the sampled suffix array values, in row order
+	n        int            // the length of the encoded text, including the sentinel
+}
+
+func New() *FMIndex {
+	return &FMIndex{}
+}
+
+// Put adds the specified index to the FM-index under the given key.
+// The key is searchable after the next Build, or the next search which rebuilds the dirty index.
+func (f *FMIndex) Put(key string, index int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.dirty = true
+
+	f.starts = append(f.starts, int32(f.length))
+	f.indexes = append(f.indexes, index)
+	for _, r := range key {
+		f.pending = append(f.pending, r)
+		f.length++
+	}
+	f.pending = append(f.pending, -1)
+	f.length++
+}
+
+// Build builds the FM-index of the keys added so far, and releases their runes.
+// The text of the keys of the previous build is recovered from the transform, so the whole text is rebuilt.
+func (f *FMIndex) Build() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.build()
+}
+
+// readLock locks the index for reading, after building it if it is dirty or has never been built.
+func (f *FMIndex) readLock() {
+	f.mu.RLock()
+	if !f.dirty && f.bwt != nil {
+		return
+	}
+	f.mu.RUnlock()
+
+	f.mu.Lock()
+	if f.dirty || f.bwt == nil {
+		f.build()
+	}
+	f.mu.Unlock()
+	// the keys put in between are searchable after the next build
+	f.mu.RLock()
+}
+
+func (f *FMIndex) build() {
+	if f.bwt != nil {
+		f.pending = append(f.extract(), f.pending...)
+	}
+
+	seen := make(map[int32]bool)
+	f.alphabet = f.alphabet[:0]
+	for _, r := range f.pending {
+		if r >= 0 && !seen[r] {
+			seen[r] = true
+			f.alphabet = append(f.alphabet, r)
+		}
+	}
+	sort.Slice(f.alphabet, func(i, j int) bool { return f.alphabet[i] < f.alphabet[j] })
+
+	text := make([]int32, len(f.pending)+1)
+	for i, r := range f.pending {
+		text[i] = f.encode(r)
+	}
+	text[len(f.pending)] = sentinel
+	f.n = len(text)
+
+	sa := suffixarray.SAIS(text, firstRune+len(f.alphabet))
+
+	alphabetSize := firstRune + len(f.alphabet)
+	f.counts = make([]int, alphabetSize+1)
+	for _, c := range text {
+		f.counts[c+1]++
+	}
+	for c := 1; c <= alphabetSize; c++ {
+		f.counts[c] += f.counts[c-1]
+	}
+
+	bwt := make([]int32, f.n)
+	f.sampled = newBitVector(f.n)
+	f.samples = f.samples[:0]
+	for row, pos := range sa {
+		if pos == 0 {
+			bwt[row] = text[f.n-1]
+		} else {
+			bwt[row] = text[pos-1]
+		}
+		if pos%SAMPLE_RATE == 0 {
+			f.sampled.set(row)
+			f.samples = append(f.samples, pos)
+		}
+	}
+	f.sampled.build()
+	f.bwt = newWaveletMatrix(bwt, alphabetSize)
+
+	f.pending = nil
+	f.dirty = false
+}
+
+// Search searches for the given word within the FM-index and returns at most the given number of matches.
+// numElements <= 0 get all matches
+func (f *FMIndex) Search(word string, numElements int) []int {
+	f.readLock()
+	defer f.mu.RUnlock()
+
+	ret := make([]int, 0)
+	seen := make(map[int]bool)
+	for _, pos := range f.locateAll(word) {
+		index := f.indexes[f.keyOf(pos)]
+		if seen[index] {
+			continue
+		}
+		seen[index] = true
+		ret = append(ret, index)
+		if numElements > 0 && len(ret) == numElements {
+			break
+		}
+	}
+	return ret
+}
+
+// Count returns the number of (possibly overlapping) occurrences of the word in the keys.
+func (f *FMIndex) Count(word string) int {
+	f.readLock()
+	defer f.mu.RUnlock()
+
+	sp, ep := f.backwardSearch(word)
+	return ep - sp
+}
+
+// Locate returns the positions, in runes from the start of the concatenated keys, of the occurrences of the word.
+func (f *FMIndex) Locate(word string) []int {
+	f.readLock()
+	defer f.mu.RUnlock()
+	return f.locateAll(word)
+}
+
+func (f *FMIndex) locateAll(word string) []int {
+	sp, ep := f.backwardSearch(word)
+	positions := make([]int, 0, ep-sp)
+	for row := sp; row < ep; row++ {
+		positions = append(positions, f.locate(row))
+	}
+	sort.Ints(positions)
+	return positions
+}
+
+// SizeInBytes returns the approximate memory used by the built index, without the keys' indexes.
+func (f *FMIndex) SizeInBytes() int {
+	f.readLock()
+	defer f.mu.RUnlock()
+	return f.bwt.sizeInBytes() + f.sampled.sizeInBytes() + len(f.samples)*4 + len(f.counts)*8 + len(f.alphabet)*4
+}
+
+// backwardSearch returns the range [sp, ep) of the suffix array rows starting with word.
+func (f *FMIndex) backwardSearch(word string) (int, int) {
+	pattern := []rune(word)
+	if len(pattern) == 0 {
+		return 0, 0
+	}
+
+	sp, ep := 0, f.n
+	for i := len(pattern) - 1; i >= 0 && sp < ep; i-- {
+		c := f.encode(pattern[i])
+		if c < 0 {
+			return 0, 0
+		}
+		sp = f.counts[c] + f.bwt.rank(c, sp)
+		ep = f.counts[c] + f.bwt.rank(c, ep)
+	}
+	return sp, ep
+}
+
+// lf returns the row of the suffix starting one position before the suffix of the given row.
+func (f *FMIndex) lf(row int) int {
+	c := f.bwt.access(row)
+	return f.counts[c] + f.bwt.rank(c, row)
+}
+
+// locate returns the text position of the suffix of the given row.
+func (f *FMIndex) locate(row int) int {
+	steps := 0
+	for !f.sampled.get(row) {
+		row = f.lf(row)
+		steps++
+	}
+	return int(f.samples[f.sampled.rank1(row)]) + steps
+}
+
+// extract recovers the text (without the sentinel) by walking the LF-mapping back from the sentinel row.
+func (f *FMIndex) extract() []int32 {
+	text := make([]int32, f.n-1)
+	row := 0 // the sentinel suffix is the smallest one
+	for pos := f.n - 2; pos >= 0; pos-- {
+		c := f.bwt.access(row)
+		if c == separator {
+			text[pos] = -1
+		} else {
+			text[pos] = f.alphabet[c-firstRune]
+		}
+		row = f.counts[c] + f.bwt.rank(c, row)
+	}
+	return text
+}
+
+// encode returns the symbol of a rune, or -1 if the rune is not in the alphabet.
+func (f *FMIndex) encode(r rune) int32 {
+	if r < 0 {
+		return separator
+	}
+	i := sort.Search(len(f.alphabet), func(i int) bool { return f.alphabet[i] >= r })
+	if i == len(f.alphabet) || f.alphabet[i] != r {
+		return -1
+	}
+	return int32(firstRune + i)
+}
+
+// keyOf returns the number of the key containing the text position pos.
+func (f *FMIndex) keyOf(pos int) int {
+	return sort.Search(len(f.starts), func(k int) bool { return int(f.starts[k]) > pos }) - 1
+}
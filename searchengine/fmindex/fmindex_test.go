@@ -0,0 +1,300 @@
+package fmindex
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	suffixarray "go4search/searchengine/suffixarray"
+)
+
+func TestFMIndex(t *testing.T) {
+	words := []string{"banana", "apple", "中文app"}
+	f := New()
+	for k, word := range words {
+		f.Put(word, k)
+	}
+
+	indexes := f.Search("a", -1)
+	if len(indexes) != 3 {
+		t.Error("indexes len should be 3,but ", len(indexes))
+	}
+
+	indexes = f.Search("文", 0)
+	if len(indexes) != 1 || indexes[0] != 2 {
+		t.Error("indexes should be [2], but ", indexes)
+	}
+
+	if indexes := f.Search("ana", 1); len(indexes) != 1 {
+		t.Error("indexes len should be limited to 1, but ", len(indexes))
+	}
+
+	// a match must not span two keys
+	if indexes := f.Search("aa", -1); len(indexes) != 0 {
+		t.Error("indexes should be empty, but ", indexes)
+	}
+
+	if count := f.Count("ana"); count != 2 {
+		t.Error("count of ana should be 2, but ", count)
+	}
+	if count := f.Count("xyz"); count != 0 {
+		t.Error("count of xyz should be 0, but ", count)
+	}
+	// positions are in runes: "banana\x00apple\x00中文app"
+	if positions := f.Locate("app"); !equalInts(positions, []int{7, 15}) {
+		t.Error("positions of app should be [7 15], but ", positions)
+	}
+}
+
+func TestFMIndexPutAfterSearch(t *testing.T) {
+	f := New()
+	f.Put("hello world", 0)
+	if indexes := f.Search("world", -1); !equalInts(indexes, []int{0}) {
+		t.Fatal("indexes should be [0], but ", indexes)
+	}
+
+	// the keys are recovered from the transform and the index is rebuilt with the new key
+	f.Put("wonderful world", 1)
+	f.Put("안녕 세계", 2)
+	if indexes := f.Search("world", -1); !equalInts(indexes, []int{0, 1}) {
+		t.Error("indexes should be [0 1], but ", indexes)
+	}
+	if indexes := f.Search("llo", -1); !equalInts(indexes, []int{0}) {
+		t.Error("indexes should be [0], but ", indexes)
+	}
+	if indexes := f.Search("세계", -1); !equalInts(indexes, []int{2}) {
+		t.Error("indexes should be [2], but ", indexes)
+	}
+}
+
+func TestFMIndexRebuildsOnceAfterPuts(t *testing.T) {
+	f := New()
+	f.Put("hello world", 0)
+	f.Build()
+	built := f.bwt
+
+	// the puts only mark the index dirty
+	f.Put("wonderful world", 1)
+	f.Put("brave new world", 2)
+	if f.bwt != built || !f.dirty {
+		t.Fatal("the index should not be rebuilt before the next search")
+	}
+
+	if indexes := f.Search("world", -1); !equalInts(indexes, []int{0, 1, 2}) {
+		t.Error("indexes should be [0 1 2], but ", indexes)
+	}
+	rebuilt := f.bwt
+	if rebuilt == built || f.dirty {
+		t.Fatal("the search should rebuild the dirty index")
+	}
+	if count := f.Count("world"); count != 3 || f.bwt != rebuilt {
+		t.Error("the clean index should not be rebuilt, count should be 3, but ", count)
+	}
+}
+
+func TestFMIndexConcurrentSearch(t *testing.T) {
+	f := New()
+	for k := 0; k < 50; k++ {
+		f.Put(fmt.Sprintf("key %d hobbit", k), k)
+	}
+
+	// the first searches build the index, while more keys are put
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				if indexes := f.Search("hobbit", -1); len(indexes) < 50 {
+					t.Errorf("expected at least 50 indexes, got %d", len(indexes))
+					return
+				}
+				f.Count("key 1")
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for k := 50; k < 60; k++ {
+			f.Put(fmt.Sprintf("key %d dragon", k), k)
+		}
+	}()
+	wg.Wait()
+
+	f.Build()
+	if indexes := f.Search("dragon", -1); len(indexes) != 10 {
+		t.Error("expected the 10 keys put concurrently, got ", indexes)
+	}
+}
+
+func TestFMIndexAgainstBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for iter := 0; iter < 200; iter++ {
+		words := randomWords(r, 1+r.Intn(8), 40, "abc")
+		f := New()
+		for k, word := range words {
+			f.Put(word, k)
+		}
+		text := strings.Join(words, "\x00") + "\x00"
+
+		for _, word := range words {
+			for i := 0; i < len(word); i++ {
+				for j := i + 1; j <= len(word) && j <= i+6; j++ {
+					sub := word[i:j]
+
+					expected := make([]int, 0)
+					for k, w := range words {
+						if strings.Contains(w, sub) {
+							expected = append(expected, k)
+						}
+					}
+					got := f.Search(sub, -1)
+					sort.Ints(got)
+					if !equalInts(got, expected) {
+						t.Fatalf("%v: search %q, expected %v, got %v", words, sub, expected, got)
+					}
+
+					positions := make([]int, 0)
+					for p := 0; p+len(sub) <= len(text); p++ {
+						if text[p:p+len(sub)] == sub {
+							positions = append(positions, p)
+						}
+					}
+					if got := f.Locate(sub); !equalInts(got, positions) {
+						t.Fatalf("%v: locate %q, expected %v, got %v", words, sub, positions, got)
+					}
+					if count := f.Count(sub); count != len(positions) {
+						t.Fatalf("%v: count %q, expected %d, got %d", words, sub, len(positions), count)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestWaveletMatrix(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for _, alphabetSize := range []int{1, 2, 3, 17, 300} {
+		symbols := make([]int32, 1+r.Intn(2000))
+		for i := range symbols {
+			symbols[i] = int32(r.Intn(alphabetSize))
+		}
+		wm := newWaveletMatrix(symbols, alphabetSize)
+
+		counts := make([]int, alphabetSize)
+		for i, c := range symbols {
+			if got := wm.access(i); got != c {
+				t.Fatalf("alphabet %d: access(%d) should be %d, but %d", alphabetSize, i, c, got)
+			}
+			if got := wm.rank(c, i); got != counts[c] {
+				t.Fatalf("alphabet %d: rank(%d, %d) should be %d, but %d", alphabetSize, c, i, counts[c], got)
+			}
+			counts[c]++
+		}
+		for c := range counts {
+			if got := wm.rank(int32(c), len(symbols)); got != counts[c] {
+				t.Fatalf("alphabet %d: rank(%d, n) should be %d, but %d", alphabetSize, c, counts[c], got)
+			}
+		}
+	}
+}
+
+func randomWords(r *rand.Rand, count int, maxLength int, alphabet string) []string {
+	words := make([]string, count)
+	for i := range words {
+		b := make([]byte, 1+r.Intn(maxLength))
+		for j := range b {
+			b[j] = alphabet[r.Intn(len(alphabet))]
+		}
+		words[i] = string(b)
+	}
+	return words
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// benchmarkCorpus returns documents made of random words, as a stand-in for crawled pages.
+func benchmarkCorpus() []string {
+	r := rand.New(rand.NewSource(42))
+	vocabulary := randomWords(r, 2000, 10, "abcdefghijklmnopqrstuvwxyz")
+	docs := make([]string, 300)
+	for i := range docs {
+		words := make([]string, 50)
+		for j := range words {
+			words[j] = vocabulary[r.Intn(len(vocabulary))]
+		}
+		docs[i] = strings.Join(words, " ")
+	}
+	return docs
+}
+
+var benchmarkQueries = []string{"ab", "the", "xyz", "lmn", "qu", "e a", "zzz"}
+
+// heapGrowth returns the number of heap bytes still allocated by build once it has returned.
+func heapGrowth(build func() interface{}) (uint64, interface{}) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	index := build()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	return after.HeapAlloc - before.HeapAlloc, index
+}
+
+func BenchmarkFMIndexBuild(b *testing.B) {
+	docs := benchmarkCorpus()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		bytes, index := heapGrowth(func() interface{} {
+			f := New()
+			for k, doc := range docs {
+				f.Put(doc, k)
+			}
+			f.Build()
+			return f
+		})
+		runtime.KeepAlive(index)
+		b.ReportMetric(float64(bytes), "heap-bytes")
+	}
+}
+
+func BenchmarkFMIndexSearch(b *testing.B) {
+	f := New()
+	for k, doc := range benchmarkCorpus() {
+		f.Put(doc, k)
+	}
+	f.Build()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Search(benchmarkQueries[i%len(benchmarkQueries)], -1)
+	}
+}
+
+func BenchmarkSuffixArraySearch(b *testing.B) {
+	sa := suffixarray.New()
+	for k, doc := range benchmarkCorpus() {
+		sa.Put(doc, k)
+	}
+	sa.Build()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sa.Search(benchmarkQueries[i%len(benchmarkQueries)], -1)
+	}
+}
@@ -0,0 +1,93 @@
+package fmindex
+
+import (
+	"math/bits"
+)
+
+// waveletMatrix stores a sequence of symbols in ceil(log2(alphabet size)) bits per symbol, and answers
+// access and rank queries in O(log(alphabet size)) time.
+//
+// Level l holds the bit l (from the most significant one) of every symbol, with the symbols stably sorted by
+// their previous bits, zeros first.
+type waveletMatrix struct {
+	levels []*bitVector
+	zeros  []int // zeros[l] is the number of zeros at level l
+	n      int
+}
+
+func newWaveletMatrix(symbols []int32, alphabetSize int) *waveletMatrix {
+	depth := bits.Len(uint(alphabetSize - 1))
+	if depth == 0 {
+		depth = 1
+	}
+	wm := &waveletMatrix{n: len(symbols)}
+
+	current := append([]int32(nil), symbols...)
+	next := make([]int32, len(symbols))
+	for level := 0; level < depth; level++ {
+		shift := depth - 1 - level
+		bv := newBitVector(len(current))
+
+		// zeros go first, ones after, both in their current order
+		zeros := 0
+		for _, c := range current {
+			if (c>>shift)&1 == 0 {
+				next[zeros] = c
+				zeros++
+			}
+		}
+		ones := zeros
+		for i, c := range current {
+			if (c>>shift)&1 == 1 {
+				bv.set(i)
+				next[ones] = c
+				ones++
+			}
+		}
+		bv.build()
+
+		wm.levels = append(wm.levels, bv)
+		wm.zeros = append(wm.zeros, zeros)
+		current, next = next, current
+	}
+	return wm
+}
+
+// access returns the symbol at position i.
+func (wm *waveletMatrix) access(i int) int32 {
+	var c int32
+	for level, bv := range wm.levels {
+		if bv.get(i) {
+			c = c<<1 | 1
+			i = wm.zeros[level] + bv.rank1(i)
+		} else {
+			c <<= 1
+			i = bv.rank0(i)
+		}
+	}
+	return c
+}
+
+// rank returns the number of occurrences of the symbol c before position i.
+func (wm *waveletMatrix) rank(c int32, i int) int {
+	start := 0
+	depth := len(wm.levels)
+	for level, bv := range wm.levels {
+		if (c>>(depth-1-level))&1 == 1 {
+			start = wm.zeros[level] + bv.rank1(start)
+			i = wm.zeros[level] + bv.rank1(i)
+		} else {
+			start = bv.rank0(start)
+			i = bv.rank0(i)
+		}
+	}
+	return i - start
+}
+
+func (wm *waveletMatrix) sizeInBytes() int {
+	size := 0
+	for _, bv := range wm.levels {
+		size += bv.sizeInBytes()
+	}
+	return size
+}
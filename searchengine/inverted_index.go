@@ -4,47 +4,108 @@ import (
 	documents "go4search/documents"
 	nlp "go4search/nlp"
 	bloomfilter "go4search/searchengine/bloomfilter"
-	"strings"
 )
 
 type InvertedIndex map[string][]int
 
+/**
+ * Add the terms of a document to the inverted index and the Bloom filter.
+ * The content is lowercased and split into the subword tokens of the pretrained tokenizer if useTokenizer is true,
+ * on white spaces otherwise, without removing the stopwords nor stemming the terms.
+ *
+ * Deprecated: use UpdateInvertedIndexWithAnalyzer with the analyzer of the search engine.
+ */
 func UpdateInvertedIndexWithDoc(index InvertedIndex, doc documents.Document, useTokenizer bool, sbf *bloomfilter.ScalableBloomFilter) {
-	// support both pre-trained sentence-piece tokenizer and simple whitespace tokenizer
-	var tokens []string
-	if useTokenizer {
-		tokens = nlp.Tokenize_Query(strings.ToLower(doc.Content))
-	} else {
-		tokens = strings.Fields(strings.ToLower(doc.Content))
-	}
+	UpdateInvertedIndexWithAnalyzer(index, doc, legacyAnalyzer(useTokenizer), sbf)
+}
 
+/**
+ * Analyze the content of a document in its language (detected if empty), and add its terms to the inverted index and the Bloom filter.
+ *
+ * @param index The inverted index to update
+ * @param doc A document
+ * @param analyzer The analyzer of the content field, the same one must be used to analyze the queries
+ * @param sbf The Bloom filter of the index terms, nil if none
+ */
+func UpdateInvertedIndexWithAnalyzer(index InvertedIndex, doc documents.Document, analyzer *nlp.Analyzer, sbf *bloomfilter.ScalableBloomFilter) {
+	addPostings(index, doc.ID, analyzer.AnalyzeLanguage(doc.Content, doc.Language), sbf)
+}
+
+// addPostings adds the analyzed tokens of a document to the inverted index, and to the Bloom filter if not nil.
+func addPostings(index InvertedIndex, docID int, tokens []nlp.Token, sbf *bloomfilter.ScalableBloomFilter) {
 	// iterate all tokens in the document, and store the document ID to the key-value store
 	for _, token := range tokens {
-		if _, ok := index[token]; !ok {
-			index[token] = make([]int, 0)
+		if _, ok := index[token.Term]; !ok {
+			index[token.Term] = make([]int, 0)
 		}
-		index[token] = append(index[token], doc.ID)
+		index[token.Term] = append(index[token.Term], docID)
 
 		// add the token to the Bloom filter
-		sbf.Add([]byte(token))
+		if sbf != nil {
+			sbf.Add([]byte(token.Term))
+		}
 	}
 }
 
 /**
  * Build an inverted index by tokenizing the documents and storing the doucment IDs to key-value store.
  * The key is the token, and the value is a slice of document IDs.
+ * The documents are lowercased and split into the subword tokens of the pretrained tokenizer if useTokenizer is true,
+ * on white spaces otherwise (see UpdateInvertedIndexWithDoc).
+ *
+ * Deprecated: use BuildInvertedIndexWithAnalyzer, or NewSearchEngine which analyzes the queries the same way.
  *
  * @param documents A slice of documents
  * @return InvertedIndex
  */
 func BuildInvertedIndex(documents []documents.Document, useTokenizer bool) (InvertedIndex, *bloomfilter.ScalableBloomFilter) {
-	index := make(InvertedIndex)
-	sbf, _ := bloomfilter.NewScalable(bloomfilter.ParamsScalable{InitialSize: 1000, FalsePositiveRate: 0.01, FalsePositiveGrowth: 2})
+	return BuildInvertedIndexWithAnalyzer(documents, legacyAnalyzer(useTokenizer))
+}
+
+/**
+ * Build an inverted index of the documents analyzed with the given analyzer.
+ *
+ * @param documents A slice of documents
+ * @param analyzer The analyzer of the content field
+ * @return (InvertedIndex, *bloomfilter.ScalableBloomFilter) the index, and the Bloom filter of its terms
+ */
+func BuildInvertedIndexWithAnalyzer(documents []documents.Document, analyzer *nlp.Analyzer) (InvertedIndex, *bloomfilter.ScalableBloomFilter) {
+	index, sbf := make(InvertedIndex), newBloomFilter()
 
 	// iterate all documents
 	for _, doc := range documents {
-		UpdateInvertedIndexWithDoc(index, doc, useTokenizer, sbf)
+		UpdateInvertedIndexWithAnalyzer(index, doc, analyzer, sbf)
 	}
 
 	return index, sbf
 }
+
+// newBloomFilter returns an empty Bloom filter for the terms of an inverted index.
+func newBloomFilter() *bloomfilter.ScalableBloomFilter {
+	sbf, _ := bloomfilter.NewScalable(bloomfilter.ParamsScalable{InitialSize: 1000, FalsePositiveRate: 0.01, FalsePositiveGrowth: 2})
+	return sbf
+}
+
+// the analyzers of the useTokenizer flag of BuildInvertedIndex, which only lowercase and split the text
+var (
+	subwordLegacyAnalyzer    = nlp.NewAnalyzer([]nlp.CharFilter{nlp.LowercaseFilter}, nlp.SubwordTokenizer{})
+	whitespaceLegacyAnalyzer = nlp.NewWhitespaceAnalyzer()
+)
+
+// legacyAnalyzer returns the analyzer matching the useTokenizer flag of BuildInvertedIndex.
+func legacyAnalyzer(useTokenizer bool) *nlp.Analyzer {
+	// support both pre-trained sentence-piece tokenizer and simple whitespace tokenizer
+	if useTokenizer {
+		return subwordLegacyAnalyzer
+	}
+	return whitespaceLegacyAnalyzer
+}
+
+// termFrequencies returns the number of occurrences of a term in each document, from the postings of the term.
+func termFrequencies(postings []int) map[int]int {
+	frequencies := make(map[int]int)
+	for _, docID := range postings {
+		frequencies[docID]++
+	}
+	return frequencies
+}
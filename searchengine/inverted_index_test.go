@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	documents "go4search/documents"
+	nlp "go4search/nlp"
 )
 
 func TestBuildInvertedIndex(t *testing.T) {
@@ -58,6 +59,21 @@ func TestBuildInvertedIndex(t *testing.T) {
 	}
 }
 
+func TestBuildInvertedIndexWithTokenizer(t *testing.T) {
+	if err := nlp.Load_Tokenizer(nlp.TokenizerConfig{Path: "../nlp/testdata/tokenizer.json"}); err != nil {
+		t.Fatal(err)
+	}
+	docs := []documents.Document{{ID: 0, Content: "The Hobbits love happiness"}}
+
+	// the content is only lowercased and split into subword tokens: the stopwords are kept, and nothing is stemmed
+	invertedIndex, _ := BuildInvertedIndex(docs, true)
+	for _, token := range []string{"the", "hobbit", "##s", "love", "happi", "##ness"} {
+		if !equalSlice(invertedIndex[token], []int{0}) {
+			t.Errorf("Expected the token %s, got %v", token, invertedIndex)
+		}
+	}
+}
+
 // Helper function to check if two slices are equal
 func equalSlice(a, b []int) bool {
 	if len(a) != len(b) {
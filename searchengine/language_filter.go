@@ -0,0 +1,151 @@
+package searchengine
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	documents "go4search/documents"
+	nlp "go4search/nlp"
+)
+
+// languageFilterPattern matches the language filters of a query, e.g. `lang:en` or `lang:korean`.
+var languageFilterPattern = regexp.MustCompile(`^(?i)lang:(\S+)$`)
+
+// LanguageStatistics describes the documents of a language.
+type LanguageStatistics struct {
+	Language          string  // the ISO 639-1 code of the language, empty for the documents whose language is unknown
+	Documents         int     // the number of documents
+	AverageConfidence float64 // the average confidence of the language detection
+	AverageLength     float64 // the average length of the documents, in bytes
+}
+
+/**
+ * Set the language of a document and its confidence, detecting them if the language is not given.
+ * A given language is turned into its ISO 639-1 code (e.g. `English` -> `en`) with a confidence of 1.
+ *
+ * @param doc A document
+ */
+func detectDocumentLanguage(doc *documents.Document) {
+	if doc.Language != "" {
+		if code, exists := nlp.LanguageCode(doc.Language); exists {
+			doc.Language = code
+			if doc.LanguageConfidence == 0 {
+				doc.LanguageConfidence = 1
+			}
+			return
+		}
+	}
+
+	doc.Language, doc.LanguageConfidence = "", 0
+	if language, confidence, exists := nlp.DetectLanguageWithConfidence(doc.Content); exists {
+		doc.Language, _ = nlp.LanguageCode(language)
+		doc.LanguageConfidence = confidence
+	}
+}
+
+/**
+ * Return the statistics of the languages of the indexed documents, sorted by decreasing number of documents.
+ *
+ * @return []LanguageStatistics
+ */
+func (se *SearchEngine) LanguageStatistics() []LanguageStatistics {
+	byLanguage := make(map[string]*LanguageStatistics)
+	for _, doc := range se.Documents {
+		stats, ok := byLanguage[doc.Language]
+		if !ok {
+			stats = &LanguageStatistics{Language: doc.Language}
+			byLanguage[doc.Language] = stats
+		}
+		stats.Documents++
+		stats.AverageConfidence += doc.LanguageConfidence
+		stats.AverageLength += float64(len(doc.Content))
+	}
+
+	statistics := make([]LanguageStatistics, 0, len(byLanguage))
+	for _, stats := range byLanguage {
+		stats.AverageConfidence /= float64(stats.Documents)
+		stats.AverageLength /= float64(stats.Documents)
+		statistics = append(statistics, *stats)
+	}
+	sort.Slice(statistics, func(i, j int) bool {
+		if statistics[i].Documents != statistics[j].Documents {
+			return statistics[i].Documents > statistics[j].Documents
+		}
+		return statistics[i].Language < statistics[j].Language
+	})
+	return statistics
+}
+
+/**
+ * Parse a `lang:` filter of a query.
+ *
+ * @param word A word of the query
+ * @return (string, bool, error) the ISO 639-1 code of the language, whether the word is a language filter,
+ *         ErrInvalidQuery if the language is unknown
+ */
+func parseLanguageFilter(word string) (string, bool, error) {
+	match := languageFilterPattern.FindStringSubmatch(word)
+	if match == nil {
+		return "", false, nil
+	}
+	code, exists := nlp.LanguageCode(match[1])
+	if !exists {
+		return "", true, fmt.Errorf("%w: unknown language %q", ErrInvalidQuery, match[1])
+	}
+	return code, true, nil
+}
+
+// filterLanguages removes the documents that are not written in one of the given languages from the scores.
+func (se *SearchEngine) filterLanguages(scores map[int]float64, languages []string) {
+	if len(languages) == 0 {
+		return
+	}
+	for docID := range scores {
+		matches := false
+		for _, language := range languages {
+			if se.Documents[docID].Language == language {
+				matches = true
+				break
+			}
+		}
+		if !matches {
+			delete(scores, docID)
+		}
+	}
+}
+
+/**
+ * Analyze the free text of a query in each of the given languages, or in each language of the indexed documents
+ * if none is given, so that the query terms match the documents analyzed with the stopwords and the stemmer of their
+ * language. The language of the query is detected if the languages of the documents are unknown.
+ *
+ * @param analyzer The analyzer of the field
+ * @param text The free text of the query
+ * @param languages The ISO 639-1 codes of the `lang:` filters of the query
+ * @return []string the distinct terms of the query, in order
+ */
+func (se *SearchEngine) analyzeQueryText(analyzer *nlp.Analyzer, text string, languages []string) []string {
+	if len(languages) == 0 {
+		for _, language := range se.indexedLanguages() {
+			if language != "" {
+				languages = append(languages, language)
+			}
+		}
+	}
+	if len(languages) == 0 {
+		return nlp.Terms(analyzer.Analyze(text))
+	}
+
+	terms := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, language := range languages {
+		for _, token := range analyzer.AnalyzeLanguage(text, language) {
+			if !seen[token.Term] {
+				seen[token.Term] = true
+				terms = append(terms, token.Term)
+			}
+		}
+	}
+	return terms
+}
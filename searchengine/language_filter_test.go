@@ -0,0 +1,112 @@
+package searchengine
+
+import (
+	"errors"
+	"testing"
+
+	documents "go4search/documents"
+	nlp "go4search/nlp"
+)
+
+var languageTestEngine = testEngine{
+	analyzer: nlp.NewAnalyzer([]nlp.CharFilter{nlp.LowercaseFilter}, nlp.WhitespaceTokenizer{}, nlp.NewStopwordFilter(""), nlp.NewStemmerFilter("")),
+	contents: []string{
+		"Paris is the capital of France and a beautiful city",
+		"Paris est la capitale de la France et les chanteuses chantent dans la rue",
+		"Berlin ist die Hauptstadt von Deutschland und eine schöne Stadt",
+		"It was a bright cold day in April and the clocks were striking thirteen",
+		"Call me Ishmael",
+		"Madrid es la capital de España y una ciudad muy bonita",
+	},
+	languages: map[int]string{4: "English"},
+}
+
+func TestDocumentLanguageDetection(t *testing.T) {
+	se := languageTestEngine.build()
+
+	for docID, expected := range []string{"en", "fr", "de", "en", "en", "es"} {
+		doc := se.Documents[docID]
+		if doc.Language != expected {
+			t.Errorf("Expected %s for document %d, got %s", expected, docID, doc.Language)
+		}
+		if doc.LanguageConfidence <= 0 || doc.LanguageConfidence > 1 {
+			t.Errorf("Unexpected confidence %f for document %d", doc.LanguageConfidence, docID)
+		}
+	}
+	// a given language is kept
+	if se.Documents[4].LanguageConfidence != 1 {
+		t.Errorf("Expected the confidence 1 for a given language, got %f", se.Documents[4].LanguageConfidence)
+	}
+
+	// each document is analyzed with the stopwords and the stemmer of its language
+	for _, stopword := range []string{"the", "la", "die", "es"} {
+		if _, ok := se.Index[stopword]; ok {
+			t.Errorf("Expected the stopword %q not to be indexed", stopword)
+		}
+	}
+	if _, ok := se.Index["chanteux"]; !ok {
+		t.Error("Expected the french stem 'chanteux' to be indexed")
+	}
+
+	docID := len(se.Documents)
+	se.AddNewDocument(documents.Document{ID: docID, Content: "Die Katze schläft auf dem Sofa in der Küche"})
+	if se.Documents[docID].Language != "de" {
+		t.Errorf("Expected de for the added document, got %s", se.Documents[docID].Language)
+	}
+}
+
+func TestDocumentLanguageCopy(t *testing.T) {
+	docs := paddedCorpus("Paris est la capitale de la France et les chanteuses chantent dans la rue")
+	se := NewSearchEngine(docs, map[string]*nlp.Analyzer{FIELD_CONTENT: newTestAnalyzer()})
+
+	// the languages are detected in the documents of the search engine, not in the given slice
+	if se.Documents[0].Language != "fr" || docs[0].Language != "" {
+		t.Errorf("Expected fr in the search engine only, got %q and %q", se.Documents[0].Language, docs[0].Language)
+	}
+}
+
+func TestSearchLanguageFilter(t *testing.T) {
+	se := languageTestEngine.build()
+
+	if results := se.Search("paris", 10); len(results) != 2 {
+		t.Errorf("Expected documents 0 and 1, got %v", results)
+	}
+	for query, docID := range map[string]int{"paris lang:fr": 1, "lang:English paris": 0, "lang:fr chanteuses": 1} {
+		results := se.Search(query, 10)
+		if len(results) != 1 || results[0].ID != docID || results[0].Language != se.Documents[docID].Language {
+			t.Errorf("%s: expected document %d, got %v", query, docID, results)
+		}
+	}
+	if results := se.Search("paris lang:de", 10); len(results) != 0 {
+		t.Errorf("Expected no results, got %v", results)
+	}
+	// several filters match any of the languages
+	if results := se.Search("paris lang:en lang:fr", 10); len(results) != 2 {
+		t.Errorf("Expected documents 0 and 1, got %v", results)
+	}
+
+	if _, err := se.SearchQuery("paris lang:klingon", 10); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("Expected ErrInvalidQuery, got %v", err)
+	}
+}
+
+func TestLanguageStatistics(t *testing.T) {
+	se := languageTestEngine.build()
+
+	statistics := se.LanguageStatistics()
+	expected := []struct {
+		language  string
+		documents int
+	}{{"en", 3 + len(fillerContents)}, {"de", 1}, {"es", 1}, {"fr", 1}}
+	if len(statistics) != len(expected) {
+		t.Fatalf("Expected %d languages, got %v", len(expected), statistics)
+	}
+	for i, stats := range statistics {
+		if stats.Language != expected[i].language || stats.Documents != expected[i].documents {
+			t.Errorf("Expected %d documents in %s, got %+v", expected[i].documents, expected[i].language, stats)
+		}
+		if stats.AverageConfidence <= 0 || stats.AverageConfidence > 1 || stats.AverageLength <= 0 {
+			t.Errorf("Unexpected statistics %+v", stats)
+		}
+	}
+}
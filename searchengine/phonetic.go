@@ -0,0 +1,75 @@
+package searchengine
+
+import (
+	"os"
+	"strings"
+
+	documents "go4search/documents"
+	nlp "go4search/nlp"
+)
+
+// FIELD_PHONETIC is a secondary field indexing the phonetic codes of the words of the content
+// (see nlp.NewPhoneticAnalyzer), so that the words sounding alike match each other, e.g. `Ismael` and `Ishmael`.
+const FIELD_PHONETIC = "content.phonetic"
+
+// PHONETIC_FIELD_WEIGHT is the weight of the matches in FIELD_PHONETIC, lower than the exact matches of the content.
+const PHONETIC_FIELD_WEIGHT = 0.5
+
+// ENV_PHONETIC indexes the phonetic codes of the words with the given encoder, `soundex` or `double_metaphone`,
+// for the phonetic search mode, which is disabled if the variable is not set.
+const ENV_PHONETIC = "GO4SEARCH_PHONETIC"
+
+/**
+ * Read the phonetic encoder of EnablePhoneticSearch from the environment: GO4SEARCH_PHONETIC,
+ * nlp.PHONETIC_SOUNDEX or nlp.PHONETIC_DOUBLE_METAPHONE in any case.
+ *
+ * @return string the lowercase encoder, empty if the phonetic search is disabled
+ */
+func PhoneticEncoderFromEnv() string {
+	return strings.ToLower(os.Getenv(ENV_PHONETIC))
+}
+
+/**
+ * Describe FIELD_PHONETIC for EngineOptions.Fields, so that the phonetic codes are indexed by NewSearchEngine
+ * along with the content, for PhoneticSearch.
+ *
+ * @param encoder The phonetic encoder, nlp.PHONETIC_SOUNDEX or nlp.PHONETIC_DOUBLE_METAPHONE
+ * @return (FieldConfig, error) the field, nlp.ErrUnknownPhoneticEncoder if the encoder is not supported
+ */
+func PhoneticFieldConfig(encoder string) (FieldConfig, error) {
+	analyzer, err := nlp.NewPhoneticAnalyzer(encoder)
+	if err != nil {
+		return FieldConfig{}, err
+	}
+	return FieldConfig{Name: FIELD_PHONETIC, Analyzer: analyzer, Weight: PHONETIC_FIELD_WEIGHT}, nil
+}
+
+/**
+ * Index the phonetic codes of the words of the documents in FIELD_PHONETIC, for PhoneticSearch.
+ * The documents are indexed again in the field, see PhoneticFieldConfig to index them once.
+ *
+ * @param encoder The phonetic encoder, nlp.PHONETIC_SOUNDEX or nlp.PHONETIC_DOUBLE_METAPHONE
+ * @return error nlp.ErrUnknownPhoneticEncoder if the encoder is not supported
+ */
+func (se *SearchEngine) EnablePhoneticSearch(encoder string) error {
+	field, err := PhoneticFieldConfig(encoder)
+	if err != nil {
+		return err
+	}
+	se.AddField(field.Name, field.Analyzer, field.Weight)
+	return nil
+}
+
+/**
+ * Search for documents as SearchQuery, also matching the words of the query by their sound (e.g. the misspelled
+ * names, `Ismael` for `Ishmael`), with PHONETIC_FIELD_WEIGHT. The exact matches still rank first, as they match
+ * both the content and FIELD_PHONETIC. Without EnablePhoneticSearch, it is the same as SearchQuery.
+ *
+ * @param query A search query
+ * @param limit The maximum number of results to return
+ *
+ * @return ([]documents.Document, error) the results, the errors of SearchQuery
+ */
+func (se *SearchEngine) PhoneticSearch(query string, limit int) ([]documents.Document, error) {
+	return se.searchWithCorrection(query, limit, searchOptions{phonetic: true})
+}
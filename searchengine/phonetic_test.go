@@ -0,0 +1,75 @@
+package searchengine
+
+import (
+	"errors"
+	"testing"
+
+	nlp "go4search/nlp"
+)
+
+var phoneticTestEngine = testEngine{
+	contents: []string{"Call me Ishmael", "Ismael sailed on the Pequod", "John Smith wrote a letter", "Mr Schmidt answered the letter"},
+}
+
+func TestPhoneticSearch(t *testing.T) {
+	se := phoneticTestEngine.build()
+	if err := se.EnablePhoneticSearch("metaphone3"); !errors.Is(err, nlp.ErrUnknownPhoneticEncoder) {
+		t.Errorf("Expected ErrUnknownPhoneticEncoder, got %v", err)
+	}
+	if err := se.EnablePhoneticSearch(nlp.PHONETIC_SOUNDEX); err != nil {
+		t.Fatal(err)
+	}
+
+	// the phonetic codes are only matched on demand
+	if results := se.Search("ishmael", 10); len(results) != 1 || results[0].ID != 0 {
+		t.Errorf("Expected document 0, got %v", results)
+	}
+	// the exact matches rank above the words sounding alike
+	results, err := se.PhoneticSearch("ishmael", 10)
+	if err != nil || len(results) != 2 || results[0].ID != 0 || results[1].ID != 1 {
+		t.Errorf("Expected documents 0 and 1, got %v, %v", results, err)
+	}
+	results, _ = se.PhoneticSearch("Ismail", 10)
+	if len(results) != 2 || results[0].ID+results[1].ID != 1 {
+		t.Errorf("Expected documents 0 and 1, got %v", results)
+	}
+
+	// Double Metaphone matches the alternate pronunciations
+	if err := se.EnablePhoneticSearch(nlp.PHONETIC_DOUBLE_METAPHONE); err != nil {
+		t.Fatal(err)
+	}
+	results, _ = se.PhoneticSearch("schmidt", 10)
+	if len(results) != 2 || results[0].ID != 3 || results[1].ID != 2 {
+		t.Errorf("Expected documents 3 and 2, got %v", results)
+	}
+}
+
+func TestPhoneticFieldConfig(t *testing.T) {
+	if _, err := PhoneticFieldConfig("metaphone3"); !errors.Is(err, nlp.ErrUnknownPhoneticEncoder) {
+		t.Errorf("Expected ErrUnknownPhoneticEncoder, got %v", err)
+	}
+	field, err := PhoneticFieldConfig(nlp.PHONETIC_SOUNDEX)
+	if err != nil || field.Name != FIELD_PHONETIC || field.Weight != PHONETIC_FIELD_WEIGHT {
+		t.Fatalf("Unexpected field %+v, %v", field, err)
+	}
+
+	// the phonetic codes are indexed by NewSearchEngine
+	engine := phoneticTestEngine
+	engine.options = EngineOptions{Fields: []FieldConfig{field}}
+	se := engine.build()
+	results, err := se.PhoneticSearch("ishmael", 10)
+	if err != nil || len(results) != 2 || results[0].ID != 0 || results[1].ID != 1 {
+		t.Errorf("Expected documents 0 and 1, got %v, %v", results, err)
+	}
+}
+
+func TestPhoneticEncoderFromEnv(t *testing.T) {
+	t.Setenv(ENV_PHONETIC, "Double_Metaphone")
+	if encoder := PhoneticEncoderFromEnv(); encoder != nlp.PHONETIC_DOUBLE_METAPHONE {
+		t.Errorf("Expected %q, got %q", nlp.PHONETIC_DOUBLE_METAPHONE, encoder)
+	}
+	t.Setenv(ENV_PHONETIC, "")
+	if encoder := PhoneticEncoderFromEnv(); encoder != "" {
+		t.Errorf("Expected the phonetic search to be disabled, got %q", encoder)
+	}
+}
@@ -0,0 +1,197 @@
+package searchengine
+
+import (
+	"math"
+	"sort"
+
+	nlp "go4search/nlp"
+)
+
+// PHRASE_WEIGHT is the weight of a phrase match, added to the scores of the terms of the phrase.
+const PHRASE_WEIGHT = 1.0
+
+// PositionalIndex stores the positions of the terms in the documents: term -> document ID -> positions,
+// in increasing order.
+type PositionalIndex map[string]map[int][]int
+
+// addPositions adds the positions of the tokens of a document to a positional index.
+func addPositions(index PositionalIndex, docID int, tokens []nlp.Token) {
+	for _, token := range tokens {
+		postings := index[token.Term]
+		if postings == nil {
+			postings = make(map[int][]int)
+			index[token.Term] = postings
+		}
+		positions := postings[docID]
+		i := sort.SearchInts(positions, token.Position)
+		if i < len(positions) && positions[i] == token.Position {
+			continue
+		}
+		positions = append(positions, 0)
+		copy(positions[i+1:], positions[i:])
+		positions[i] = token.Position
+		postings[docID] = positions
+	}
+}
+
+// hasPosition returns whether a term is at a position of a document in a positional index.
+func (index PositionalIndex) hasPosition(term string, docID int, position int) bool {
+	positions := index[term][docID]
+	i := sort.SearchInts(positions, position)
+	return i < len(positions) && positions[i] == position
+}
+
+// SetKeepPhraseStopwords enables matching the stopwords of the phrase queries, even if they are not indexed.
+// The positions of the terms are indexed again if the setting changes.
+func (se *SearchEngine) SetKeepPhraseStopwords(keep bool) {
+	if keep == se.KeepPhraseStopwords && se.Positions != nil {
+		return
+	}
+	se.KeepPhraseStopwords = keep
+
+	analyzer := se.phraseAnalyzer(se.Analyzer(FIELD_CONTENT))
+	se.Positions = make(PositionalIndex)
+	for _, doc := range se.Documents {
+		addPositions(se.Positions, doc.ID, analyzer.AnalyzeLanguage(doc.Content, doc.Language))
+	}
+}
+
+// phraseAnalyzer returns the analyzer comparing the phrases with the documents, which keeps the stopwords if
+// KeepPhraseStopwords is set.
+func (se *SearchEngine) phraseAnalyzer(analyzer *nlp.Analyzer) *nlp.Analyzer {
+	if !se.KeepPhraseStopwords {
+		return analyzer
+	}
+	return analyzer.WithStopwords(nlp.NewCustomStopwordFilter(nil))
+}
+
+/**
+ * Find the documents containing a phrase, i.e. its terms at the same relative positions as in the phrase.
+ * The phrase is analyzed in each language, and its terms are looked up in the positional index, so that the
+ * documents are not analyzed again.
+ *
+ * @param analyzer The analyzer of the field
+ * @param phrase The text of the phrase, without the double quotes
+ * @param languages The languages of the `lang:` filters of the query, all the languages of the documents if empty
+ * @return (map[int]bool, bool) the matching documents, and false if the phrase has no term, e.g. only stopwords
+ */
+func (se *SearchEngine) matchPhrase(analyzer *nlp.Analyzer, phrase string, languages []string) (map[int]bool, bool) {
+	if len(languages) == 0 {
+		languages = se.indexedLanguages()
+	}
+	analyzer = se.phraseAnalyzer(analyzer)
+
+	matches := make(map[int]bool)
+	hasTerms := false
+	for _, language := range languages {
+		pattern := analyzer.AnalyzeLanguage(phrase, language)
+		if len(pattern) == 0 {
+			continue
+		}
+		hasTerms = true
+
+		for _, docID := range se.phraseCandidates(pattern, language) {
+			if se.containsPhrase(docID, pattern) {
+				matches[docID] = true
+			}
+		}
+	}
+	return matches, hasTerms
+}
+
+/**
+ * Return the documents of a language containing all the terms of a phrase, in increasing order of ID.
+ * The postings of the rarest term are filtered with the other ones.
+ *
+ * @param pattern The tokens of the phrase, at least one
+ * @param language The ISO 639-1 code of the language of the documents, empty for the documents of unknown language
+ * @return []int
+ */
+func (se *SearchEngine) phraseCandidates(pattern []nlp.Token, language string) []int {
+	rarest := se.Positions[pattern[0].Term]
+	for _, token := range pattern[1:] {
+		if postings := se.Positions[token.Term]; len(postings) < len(rarest) {
+			rarest = postings
+		}
+	}
+
+	docIDs := make([]int, 0, len(rarest))
+	for docID := range rarest {
+		if docID >= len(se.Documents) || se.Documents[docID].Language != language {
+			continue
+		}
+		hasTerms := true
+		for _, token := range pattern {
+			if _, ok := se.Positions[token.Term][docID]; !ok {
+				hasTerms = false
+				break
+			}
+		}
+		if hasTerms {
+			docIDs = append(docIDs, docID)
+		}
+	}
+	sort.Ints(docIDs)
+	return docIDs
+}
+
+// containsPhrase returns whether a document contains the tokens of a phrase at the same relative positions.
+// Several tokens can share a position, e.g. the bigrams and the unigrams of the CJK tokenizer.
+func (se *SearchEngine) containsPhrase(docID int, pattern []nlp.Token) bool {
+	first := pattern[0]
+	for _, position := range se.Positions[first.Term][docID] {
+		offset := position - first.Position
+		matches := true
+		for _, next := range pattern[1:] {
+			if !se.Positions.hasPosition(next.Term, docID, next.Position+offset) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * Add the score of the phrases to the documents containing them, and remove the documents missing any phrase.
+ * The score of a phrase is higher when it is found in fewer documents, as the IDF of a term.
+ *
+ * @param scores The score of each document
+ * @param phrases The documents containing each phrase of the query
+ */
+func (se *SearchEngine) scorePhrases(scores map[int]float64, phrases []map[int]bool) {
+	for _, matches := range phrases {
+		if len(matches) == 0 {
+			clear(scores)
+			return
+		}
+		idf := math.Log(1 + float64(len(se.Documents))/float64(len(matches)))
+		for docID := range matches {
+			scores[docID] += idf * PHRASE_WEIGHT
+		}
+	}
+	for docID := range scores {
+		for _, matches := range phrases {
+			if !matches[docID] {
+				delete(scores, docID)
+				break
+			}
+		}
+	}
+}
+
+// indexedLanguages returns the languages of the indexed documents in order, "" for the documents of unknown language.
+func (se *SearchEngine) indexedLanguages() []string {
+	if len(se.Languages) == 0 {
+		return []string{""}
+	}
+	languages := make([]string, 0, len(se.Languages))
+	for language := range se.Languages {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
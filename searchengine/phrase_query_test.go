@@ -0,0 +1,123 @@
+package searchengine
+
+import (
+	"testing"
+
+	documents "go4search/documents"
+	nlp "go4search/nlp"
+)
+
+var phraseTestEngine = testEngine{
+	contents: []string{
+		"In a hole in the ground there lived a hobbit",
+		"The ground of the hobbit hole",
+		"To be or not to be that is the question",
+		"It is not to be taken lightly",
+	},
+}
+
+func TestSearchPhrase(t *testing.T) {
+	se := phraseTestEngine.build()
+
+	if results := se.Search("hobbit hole", 10); len(results) != 2 {
+		t.Errorf("Expected documents 0 and 1, got %v", results)
+	}
+	if results := se.Search(`"hobbit hole"`, 10); len(results) != 1 || results[0].ID != 1 {
+		t.Errorf("Expected document 1, got %v", results)
+	}
+	// the stopwords of the phrase are not indexed, but their positions are kept
+	if results := se.Search(`"hole in the ground"`, 10); len(results) != 1 || results[0].ID != 0 {
+		t.Errorf("Expected document 0, got %v", results)
+	}
+	if results := se.Search(`"hole of a ground"`, 10); len(results) != 1 || results[0].ID != 0 {
+		t.Errorf("Expected document 0, got %v", results)
+	}
+	if results := se.Search(`"ground hole"`, 10); len(results) != 0 {
+		t.Errorf("Expected no results, got %v", results)
+	}
+	// a phrase made of stopwords only is ignored
+	if results := se.Search(`"to be or not to be"`, 10); len(results) != 0 {
+		t.Errorf("Expected no results, got %v", results)
+	}
+}
+
+func TestSearchPhraseKeepStopwords(t *testing.T) {
+	engine := phraseTestEngine
+	engine.options = EngineOptions{KeepPhraseStopwords: true}
+	se := engine.build()
+	if positions := se.Positions["be"][2]; !equalSlice(positions, []int{1, 5}) {
+		t.Errorf("Expected the positions 1 and 5 of 'be' in document 2 at index time, got %v", positions)
+	}
+
+	if results := se.Search(`"hole in the ground"`, 10); len(results) != 1 || results[0].ID != 0 {
+		t.Errorf("Expected document 0, got %v", results)
+	}
+	if results := se.Search(`"hole of a ground"`, 10); len(results) != 0 {
+		t.Errorf("Expected no results, got %v", results)
+	}
+	if results := se.Search(`"to be or not to be"`, 10); len(results) != 1 || results[0].ID != 2 {
+		t.Errorf("Expected document 2, got %v", results)
+	}
+	if results := se.Search(`"not to be"`, 10); len(results) != 2 {
+		t.Errorf("Expected documents 2 and 3, got %v", results)
+	}
+}
+
+func TestPhrasePositions(t *testing.T) {
+	se := phraseTestEngine.build()
+	if _, ok := se.Positions["be"]; ok {
+		t.Fatal("Expected the positions of the stopword 'be' not to be indexed")
+	}
+	if positions := se.Positions["hobbit"][0]; !equalSlice(positions, []int{9}) {
+		t.Errorf("Expected the position 9 of 'hobbit' in document 0, got %v", positions)
+	}
+
+	// the positions are indexed again with the stopwords of the phrases, and updated with the new documents
+	se.SetKeepPhraseStopwords(true)
+	if positions := se.Positions["be"][2]; !equalSlice(positions, []int{1, 5}) {
+		t.Errorf("Expected the positions 1 and 5 of 'be' in document 2, got %v", positions)
+	}
+	se.AddNewDocument(documents.Document{ID: len(se.Documents), Content: "Whether to be or not to be", Language: "en"})
+	if results := se.Search(`"to be or not to be"`, 10); len(results) != 2 {
+		t.Errorf("Expected documents 2 and %d, got %v", len(se.Documents)-1, results)
+	}
+
+	se.SetKeepPhraseStopwords(false)
+	if _, ok := se.Positions["be"]; ok {
+		t.Error("Expected the positions of the stopwords to be removed")
+	}
+}
+
+func TestSetStopwords(t *testing.T) {
+	se := phraseTestEngine.build()
+	if _, ok := se.Index["the"]; ok {
+		t.Fatal("Expected the stopword 'the' not to be indexed")
+	}
+
+	if err := se.SetStopwords(nlp.StopwordConfig{Disabled: true}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := se.Index["the"]; !ok {
+		t.Error("Expected the documents to be indexed again with the stopwords")
+	}
+	if !se.TermDictionary().contains("the") {
+		t.Error("Expected the term dictionary to be rebuilt")
+	}
+
+	if err := se.SetStopwords(nlp.StopwordConfig{Words: []string{"hobbit"}, KeepInPhrases: true}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := se.Index["hobbit"]; ok {
+		t.Error("Expected the custom stopword 'hobbit' not to be indexed")
+	}
+	if results := se.Search(`"hobbit hole"`, 10); len(results) != 1 || results[0].ID != 1 || !se.KeepPhraseStopwords {
+		t.Errorf("Expected document 1, got %v", results)
+	}
+
+	if err := se.SetStopwords(nlp.StopwordConfig{Language: "klingon"}); err == nil {
+		t.Error("Expected an error for an unknown language")
+	}
+	if _, ok := se.Index["hobbit"]; ok {
+		t.Error("Expected the index to be left unchanged")
+	}
+}
@@ -0,0 +1,100 @@
+package searchengine
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	nlp "go4search/nlp"
+)
+
+var ErrInvalidQuery = errors.New("invalid query")
+
+var fuzzyTermPattern = regexp.MustCompile(`^(.+)~([0-9]*)$`)
+
+// phrasePattern matches the phrases of a query, written between double quotes.
+var phrasePattern = regexp.MustCompile(`"([^"]*)"`)
+
+// fuzzyTerm is a term to be matched approximately, within the given edit distance.
+type fuzzyTerm struct {
+	term     string
+	distance int
+}
+
+// parsedQuery is a user input query split into the free text, which goes through the regular query processing,
+// and the terms written with a special query syntax.
+type parsedQuery struct {
+	text      string      // the free text of the query
+	wildcards []string    // prefix and wildcard patterns such as `hobb*`, `*ness` or `b?g`
+	fuzzy     []fuzzyTerm // fuzzy terms such as `hobit~1`
+	languages []string    // the ISO 639-1 codes of the `lang:` filters, such as `lang:en`
+	phrases   []string    // the phrases written between double quotes, such as `"hobbit hole"`
+	chosung   []string    // the initial consonants of Hangul words such as `ㅇㅇㅇ`, or `ㅇㅇ*` for their prefix
+}
+
+/**
+ * Split the user input query into free text and term patterns.
+ * A whitespace separated word is a wildcard pattern if it contains `*`, or `?` anywhere but at its end,
+ * since a trailing `?` is most likely the question mark of a natural language query.
+ * A word ending with `~N` is a fuzzy term matching within N edits (MAX_EDIT_DISTANCE if N is omitted).
+ * The text between double quotes is a phrase, matching the documents where its words are next to each other, in order.
+ * A word `lang:xx` restricts the results to the documents written in a language, given by its name or its ISO 639-1 code.
+ * A word made of Hangul initial consonants (e.g. `ㅇㅇㅇ`, or `ㅇㅇ*` for a prefix) is a chosung pattern.
+ *
+ * @param query A search query
+ * @return (parsedQuery, error) the parsed query,
+ *         ErrInvalidQuery if a fuzzy distance is out of range or a filtered language is unknown
+ */
+func parseQuery(query string) (parsedQuery, error) {
+	parsed := parsedQuery{wildcards: make([]string, 0), fuzzy: make([]fuzzyTerm, 0)}
+
+	for _, match := range phrasePattern.FindAllStringSubmatch(query, -1) {
+		if phrase := strings.TrimSpace(match[1]); phrase != "" {
+			parsed.phrases = append(parsed.phrases, phrase)
+		}
+	}
+	query = phrasePattern.ReplaceAllString(query, " ")
+
+	words := make([]string, 0)
+	for _, word := range strings.Fields(query) {
+		if language, ok, err := parseLanguageFilter(word); ok {
+			if err != nil {
+				return parsedQuery{}, err
+			}
+			parsed.languages = append(parsed.languages, language)
+			continue
+		}
+		if nlp.IsChosung(strings.TrimSuffix(word, "*")) {
+			parsed.chosung = append(parsed.chosung, word)
+			continue
+		}
+		if match := fuzzyTermPattern.FindStringSubmatch(word); match != nil {
+			distance := MAX_EDIT_DISTANCE
+			if match[2] != "" {
+				distance, _ = strconv.Atoi(match[2])
+			}
+			if distance > MAX_EDIT_DISTANCE {
+				return parsedQuery{}, fmt.Errorf("%w: edit distance of %q must be at most %d", ErrInvalidQuery, word, MAX_EDIT_DISTANCE)
+			}
+			parsed.fuzzy = append(parsed.fuzzy, fuzzyTerm{term: strings.ToLower(match[1]), distance: distance})
+			continue
+		}
+		if isWildcardPattern(word) {
+			parsed.wildcards = append(parsed.wildcards, strings.ToLower(word))
+			continue
+		}
+		words = append(words, word)
+	}
+	parsed.text = strings.Join(words, " ")
+
+	return parsed, nil
+}
+
+func isWildcardPattern(word string) bool {
+	if strings.Contains(word, "*") {
+		return true
+	}
+	return strings.Contains(strings.TrimRight(word, "?"), "?")
+}
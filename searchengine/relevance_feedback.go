@@ -0,0 +1,261 @@
+package searchengine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	nlp "go4search/nlp"
+)
+
+const (
+	// the term weighting methods of the pseudo relevance feedback
+	FEEDBACK_RM3     = "rm3"
+	FEEDBACK_ROCCHIO = "rocchio"
+
+	DEFAULT_FEEDBACK_DOCUMENTS = 10
+	DEFAULT_FEEDBACK_TERMS     = 10
+	DEFAULT_FEEDBACK_WEIGHT    = 0.5
+
+	// ENV_FEEDBACK enables the pseudo relevance feedback with the given method, `rm3` or `rocchio`,
+	// it is disabled if the variable is not set.
+	ENV_FEEDBACK = "GO4SEARCH_FEEDBACK"
+)
+
+var ErrInvalidFeedback = errors.New("invalid relevance feedback configuration")
+
+// FeedbackConfig configures the pseudo relevance feedback, see SetFeedback.
+type FeedbackConfig struct {
+	// Method is the weighting of the expansion terms, FEEDBACK_RM3 or FEEDBACK_ROCCHIO.
+	Method string
+	// Documents is the number of top documents of the first pass assumed to be relevant.
+	Documents int
+	// Terms is the number of expansion terms taken from these documents.
+	Terms int
+	// Weight is the interpolation weight of the expansion terms in the expanded query, between 0 and 1.
+	// The terms of the original query are weighted with 1 - Weight.
+	Weight float64
+}
+
+// DefaultFeedbackConfig returns the configuration of RM3 with 10 documents, 10 terms and an interpolation weight of 0.5.
+func DefaultFeedbackConfig() FeedbackConfig {
+	return FeedbackConfig{
+		Method:    FEEDBACK_RM3,
+		Documents: DEFAULT_FEEDBACK_DOCUMENTS,
+		Terms:     DEFAULT_FEEDBACK_TERMS,
+		Weight:    DEFAULT_FEEDBACK_WEIGHT,
+	}
+}
+
+/**
+ * Check the configuration of the pseudo relevance feedback.
+ *
+ * @return error ErrInvalidFeedback if the method is unknown, or a number or the weight is out of range
+ */
+func (c *FeedbackConfig) Validate() error {
+	if c.Method != FEEDBACK_RM3 && c.Method != FEEDBACK_ROCCHIO {
+		return fmt.Errorf("%w: unknown method %q", ErrInvalidFeedback, c.Method)
+	}
+	if c.Documents <= 0 || c.Terms <= 0 {
+		return fmt.Errorf("%w: the numbers of documents and terms must be positive", ErrInvalidFeedback)
+	}
+	if c.Weight < 0 || c.Weight > 1 {
+		return fmt.Errorf("%w: the weight %v must be between 0 and 1", ErrInvalidFeedback, c.Weight)
+	}
+	return nil
+}
+
+/**
+ * Read the pseudo relevance feedback configuration from the environment: GO4SEARCH_FEEDBACK, the method
+ * (FEEDBACK_RM3 or FEEDBACK_ROCCHIO, in any case) of the DefaultFeedbackConfig.
+ *
+ * @return *FeedbackConfig the configuration, nil if the feedback is disabled
+ */
+func FeedbackConfigFromEnv() *FeedbackConfig {
+	method := os.Getenv(ENV_FEEDBACK)
+	if method == "" {
+		return nil
+	}
+	config := DefaultFeedbackConfig()
+	config.Method = strings.ToLower(method)
+	return &config
+}
+
+/**
+ * Enable the pseudo relevance feedback: the top documents of a first retrieval pass are assumed to be relevant,
+ * their most representative terms are added to the query, and the expanded query is run again.
+ *
+ * With RM3, the weight of a term is its probability in the top documents, each document weighted with its score.
+ * With Rocchio, it is the average TF-IDF of the term in the top documents.
+ *
+ * @param config The configuration, e.g. DefaultFeedbackConfig(), nil to disable the feedback
+ * @return error ErrInvalidFeedback if the configuration is invalid, see FeedbackConfig.Validate
+ */
+func (se *SearchEngine) SetFeedback(config *FeedbackConfig) error {
+	if config == nil {
+		se.Feedback = nil
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	feedback := *config
+	se.Feedback = &feedback
+	return nil
+}
+
+/**
+ * Expand the query terms with the terms of the top documents of the first pass (see SetFeedback).
+ * The expanded query interpolates the original query and the expansion terms as RM3 does:
+ * P'(t|q) = (1 - Weight) * P(t|q) + Weight * P(t|R), where P(t|q) = w(t) / W are the weights of the original query
+ * normalized by their sum W, and P(t|R) the feedback weights of the expansion terms normalized to sum to 1.
+ * The expanded weights are W * P'(t|q), so that the scores of the expanded query are on the scale of the first pass.
+ *
+ * @param scores The scores of the first pass
+ * @param terms The terms of the original query
+ * @return []queryTerm the expanded query, empty if there is no relevant document
+ */
+func (se *SearchEngine) expandWithFeedback(scores map[int]float64, terms []queryTerm) []queryTerm {
+	config := se.Feedback
+	top := topDocuments(scores, config.Documents)
+	if len(top) == 0 {
+		return []queryTerm{}
+	}
+
+	var weights map[string]float64
+	if config.Method == FEEDBACK_ROCCHIO {
+		weights = se.rocchioWeights(top)
+	} else {
+		weights = se.rm3Weights(top, scores)
+	}
+	feedback := selectFeedbackTerms(weights, config.Terms)
+
+	totalWeight := 0.0
+	expanded := make([]queryTerm, 0, len(terms)+len(feedback))
+	contentTerms := make(map[string]int)
+	for _, term := range terms {
+		totalWeight += term.weight
+		// the expansion terms are content terms, which the query may qualify with FIELD_CONTENT
+		if term.field == "" || term.field == FIELD_CONTENT {
+			contentTerms[term.token] = len(expanded)
+		}
+		expanded = append(expanded, queryTerm{token: term.token, weight: (1 - config.Weight) * term.weight, field: term.field})
+	}
+	for _, term := range feedback {
+		weight := config.Weight * totalWeight * term.weight
+		if i, ok := contentTerms[term.token]; ok {
+			expanded[i].weight += weight
+			continue
+		}
+		expanded = append(expanded, queryTerm{token: term.token, weight: weight})
+	}
+	return expanded
+}
+
+// topDocuments returns the documents with the highest scores, at least SCORE_THRESHOLD, the best first.
+func topDocuments(scores map[int]float64, n int) []int {
+	docIDs := make([]int, 0, len(scores))
+	for docID, score := range scores {
+		if score >= SCORE_THRESHOLD {
+			docIDs = append(docIDs, docID)
+		}
+	}
+	sort.Slice(docIDs, func(i, j int) bool {
+		if scores[docIDs[i]] != scores[docIDs[j]] {
+			return scores[docIDs[i]] > scores[docIDs[j]]
+		}
+		return docIDs[i] < docIDs[j]
+	})
+	if len(docIDs) > n {
+		return docIDs[:n]
+	}
+	return docIDs
+}
+
+// termVector returns the frequency of each term of the analyzed tokens of a document.
+func termVector(tokens []nlp.Token) map[string]int {
+	frequencies := make(map[string]int)
+	for _, token := range tokens {
+		frequencies[token.Term]++
+	}
+	return frequencies
+}
+
+// documentTerms returns the frequency of each term of a document and its length, from the term vectors of the index.
+// The document is analyzed again if it has not been indexed by the search engine, e.g. with BuildInvertedIndex.
+func (se *SearchEngine) documentTerms(docID int) (map[string]int, int) {
+	if docID < len(se.TermVectors) && docID < len(se.DocLengths) {
+		return se.TermVectors[docID], se.DocLengths[docID]
+	}
+	doc := se.Documents[docID]
+	tokens := se.Analyzer(FIELD_CONTENT).AnalyzeLanguage(doc.Content, doc.Language)
+	return termVector(tokens), len(tokens)
+}
+
+// rm3Weights returns the relevance model of the top documents: P(t|R) = sum of P(t|d) * P(d|q) over the documents,
+// where P(t|d) is the frequency of the term in the document, and P(d|q) its normalized score.
+func (se *SearchEngine) rm3Weights(top []int, scores map[int]float64) map[string]float64 {
+	totalScore := 0.0
+	for _, docID := range top {
+		totalScore += scores[docID]
+	}
+
+	weights := make(map[string]float64)
+	for _, docID := range top {
+		frequencies, length := se.documentTerms(docID)
+		if length == 0 {
+			continue
+		}
+		for term, frequency := range frequencies {
+			weights[term] += float64(frequency) / float64(length) * scores[docID] / totalScore
+		}
+	}
+	return weights
+}
+
+// rocchioWeights returns the centroid of the TF-IDF vectors of the top documents.
+func (se *SearchEngine) rocchioWeights(top []int) map[string]float64 {
+	weights := make(map[string]float64)
+	for _, docID := range top {
+		frequencies, length := se.documentTerms(docID)
+		if length == 0 {
+			continue
+		}
+		for term, frequency := range frequencies {
+			idf := math.Log(float64(len(se.Documents)) / float64(max(1, documentFrequency(se.Index[term]))))
+			weights[term] += float64(frequency) / float64(length) * idf / float64(len(top))
+		}
+	}
+	return weights
+}
+
+// selectFeedbackTerms returns the n terms with the highest weights, with their weights normalized to sum to 1.
+func selectFeedbackTerms(weights map[string]float64, n int) []queryTerm {
+	terms := make([]queryTerm, 0, len(weights))
+	for term, weight := range weights {
+		if weight > 0 {
+			terms = append(terms, queryTerm{token: term, weight: weight})
+		}
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].weight != terms[j].weight {
+			return terms[i].weight > terms[j].weight
+		}
+		return terms[i].token < terms[j].token
+	})
+	if len(terms) > n {
+		terms = terms[:n]
+	}
+
+	total := 0.0
+	for _, term := range terms {
+		total += term.weight
+	}
+	for i := range terms {
+		terms[i].weight /= total
+	}
+	return terms
+}
@@ -0,0 +1,162 @@
+package searchengine
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	documents "go4search/documents"
+)
+
+var feedbackTestEngine = testEngine{
+	contents: []string{
+		"The hobbit Bilbo Baggins lived in the Shire",
+		"A hobbit of the Shire left Bag End with Gandalf",
+		"Frodo Baggins left the Shire with Gandalf",
+	},
+}
+
+func TestSearchWithFeedback(t *testing.T) {
+	for _, method := range []string{FEEDBACK_RM3, FEEDBACK_ROCCHIO} {
+		se := feedbackTestEngine.build()
+		before := se.Search("hobbit bilbo lived", 10)
+		if len(before) != 2 || before[0].ID != 0 || before[1].ID != 1 {
+			t.Fatalf("%s: expected documents 0 and 1 without feedback, got %v", method, before)
+		}
+
+		config := DefaultFeedbackConfig()
+		config.Method = method
+		if err := se.SetFeedback(&config); err != nil {
+			t.Fatalf("%s: unexpected error: %v", method, err)
+		}
+		// the document sharing the Shire and Gandalf with the top documents scores higher
+		results := se.Search("hobbit bilbo lived", 10)
+		if len(results) < 2 || results[1].ID != 1 || results[1].Score <= before[1].Score {
+			t.Errorf("%s: expected document 1 to score higher than %v, got %v", method, before[1].Score, results)
+		}
+		// with RM3, the document about the Shire, Baggins and Gandalf is found with the terms of the top documents
+		if method == FEEDBACK_RM3 && (len(results) != 3 || results[2].ID != 2) {
+			t.Errorf("%s: expected documents 0, 1 and 2, got %v", method, results)
+		}
+
+		// without weight, the expansion terms do not count
+		config.Weight = 0
+		if err := se.SetFeedback(&config); err != nil {
+			t.Fatalf("%s: unexpected error: %v", method, err)
+		}
+		if results := se.Search("hobbit bilbo lived", 10); len(results) != 2 {
+			t.Errorf("%s: expected documents 0 and 1, got %v", method, results)
+		}
+	}
+}
+
+func TestDocumentTerms(t *testing.T) {
+	se := feedbackTestEngine.build()
+	frequencies, length := se.documentTerms(0)
+	if length != 5 || frequencies["hobbit"] != 1 || frequencies["shire"] != 1 || frequencies["the"] != 0 {
+		t.Errorf("Expected the indexed terms of document 0, got %v and %d", frequencies, length)
+	}
+
+	// the term vectors are kept up to date with the new documents
+	se.AddNewDocument(documents.Document{ID: len(se.Documents), Content: "Gandalf and Gandalf", Language: "en"})
+	if frequencies, length := se.documentTerms(len(se.Documents) - 1); length != 2 || frequencies["gandalf"] != 2 {
+		t.Errorf("Expected gandalf twice, got %v and %d", frequencies, length)
+	}
+}
+
+func TestExpandWithFeedback(t *testing.T) {
+	se := feedbackTestEngine.build()
+	config := DefaultFeedbackConfig()
+	if err := se.SetFeedback(&config); err != nil {
+		t.Fatal(err)
+	}
+	terms := newQueryTerms([]string{"hobbit", "bilbo"}, 1.0)
+	expanded := se.expandWithFeedback(se.scoreTerms(terms), terms)
+
+	// the expanded query is the RM3 interpolation of two distributions, scaled by the total weight of the query
+	total := 0.0
+	for _, term := range expanded {
+		total += term.weight
+	}
+	if math.Abs(total-2) > 1e-9 {
+		t.Errorf("Expected a total weight of 2, got %v in %v", total, expanded)
+	}
+	if len(expanded) <= len(terms) || expanded[0].token != "hobbit" || expanded[0].weight <= 1-config.Weight {
+		t.Errorf("Expected hobbit to get a part of the feedback weight, got %v", expanded)
+	}
+}
+
+func TestSetFeedback(t *testing.T) {
+	se := feedbackTestEngine.build()
+	for _, config := range []FeedbackConfig{
+		{Method: "bm25", Documents: 10, Terms: 10, Weight: 0.5},
+		{Method: FEEDBACK_RM3, Documents: 0, Terms: 10, Weight: 0.5},
+		{Method: FEEDBACK_RM3, Documents: 10, Terms: 10, Weight: 1.5},
+	} {
+		if err := se.SetFeedback(&config); !errors.Is(err, ErrInvalidFeedback) {
+			t.Errorf("%+v: expected ErrInvalidFeedback, got %v", config, err)
+		}
+	}
+	if se.Feedback != nil {
+		t.Errorf("Expected the feedback to stay disabled, got %+v", se.Feedback)
+	}
+
+	config := DefaultFeedbackConfig()
+	if err := se.SetFeedback(&config); err != nil || se.Feedback == nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := se.SetFeedback(nil); err != nil || se.Feedback != nil {
+		t.Errorf("Expected the feedback to be disabled, got %+v", se.Feedback)
+	}
+}
+
+func TestExpandFieldQueryWithFeedback(t *testing.T) {
+	engine := feedbackTestEngine
+	engine.options = EngineOptions{
+		Fields:   []FieldConfig{{Name: "words", Analyzer: newTestAnalyzer(), Weight: 0.5}},
+		Feedback: &FeedbackConfig{Method: FEEDBACK_RM3, Documents: 2, Terms: 5, Weight: 0.5},
+	}
+	se := engine.build()
+	if se.Feedback == nil || se.Feedback.Documents != 2 {
+		t.Fatalf("Expected the feedback of the options, got %+v", se.Feedback)
+	}
+
+	// the content terms qualified with FIELD_CONTENT are merged with the expansion terms as the unqualified ones
+	terms := []queryTerm{
+		{token: "hobbit", weight: 1.0, field: FIELD_CONTENT},
+		{token: "bilbo", weight: 1.0},
+		{token: "hobbit", weight: 0.5, field: "words"},
+	}
+	expanded := se.expandWithFeedback(se.scoreTerms(terms), terms)
+	occurrences := make(map[string]int)
+	for _, term := range expanded {
+		if term.field == "" || term.field == FIELD_CONTENT {
+			occurrences[term.token]++
+		}
+	}
+	if occurrences["hobbit"] != 1 || occurrences["bilbo"] != 1 || expanded[0].weight <= 1-se.Feedback.Weight {
+		t.Errorf("Expected hobbit and bilbo once, hobbit with a part of the feedback weight, got %v", expanded)
+	}
+	// the terms of the secondary fields are kept as they are, with their fields
+	if expanded[2].token != "hobbit" || expanded[2].field != "words" || expanded[2].weight != 0.25 {
+		t.Errorf("Expected the term of the words field with the weight 0.25, got %v", expanded[2])
+	}
+}
+
+func TestFeedbackConfigFromEnv(t *testing.T) {
+	t.Setenv(ENV_FEEDBACK, "Rocchio")
+	if config := FeedbackConfigFromEnv(); config == nil || config.Method != FEEDBACK_ROCCHIO || config.Documents != DEFAULT_FEEDBACK_DOCUMENTS {
+		t.Errorf("Unexpected configuration %+v", config)
+	}
+	t.Setenv(ENV_FEEDBACK, "")
+	if config := FeedbackConfigFromEnv(); config != nil {
+		t.Errorf("Expected the feedback to be disabled, got %+v", config)
+	}
+}
+
+func TestSelectFeedbackTerms(t *testing.T) {
+	terms := selectFeedbackTerms(map[string]float64{"shire": 0.4, "gandalf": 0.2, "baggins": 0.2, "the": 0}, 2)
+	if len(terms) != 2 || terms[0] != (queryTerm{token: "shire", weight: 2.0 / 3}) || terms[1] != (queryTerm{token: "baggins", weight: 1.0 / 3}) {
+		t.Errorf("Unexpected terms %v", terms)
+	}
+}
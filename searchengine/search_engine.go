@@ -1,9 +1,12 @@
 package searchengine
 
 import (
+	"log"
 	"math"
 	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	documents "go4search/documents"
 	nlp "go4search/nlp"
@@ -12,20 +15,122 @@ import (
 
 type SearchEngine struct {
 	Index         InvertedIndex
+	Unstemmed     InvertedIndex // the content words as they are written, nil if the content analyzer keeps them, see FIELD_UNSTEMMED
 	Documents     []documents.Document
+	DocLengths    []int            // the number of analyzed tokens of each document in the content field
+	TermVectors   []map[string]int // the frequency of each content term in each document, see documentTerms
 	TotalDocCount float64
-	TotalDocLen   float64
-	AvgDocLength  float64
+	TotalDocLen   float64 // the total number of analyzed tokens of the documents
+	AvgDocLength  float64 // the average number of analyzed tokens of the documents
 	K1            float64
 	B             float64
 	Bloomfilter   *bloomfilter.ScalableBloomFilter
+	Dictionary    *TermDictionary
+	Corrector     *SpellCorrector
+	AutoCorrect   bool
+	Substrings    SubstringIndex           // nil if the substring search mode is disabled
+	Analyzers     map[string]*nlp.Analyzer // the analyzer of each field, see Analyzer
+	Languages     map[string]int           // the number of documents of each language code, "" if unknown
+	// KeepPhraseStopwords matches the stopwords of the phrase queries, see SetKeepPhraseStopwords
+	KeepPhraseStopwords bool
+	Positions           PositionalIndex          // the positions of the content terms, with the phrase stopwords
+	FieldIndexes        map[string]InvertedIndex // the index of each secondary field, see AddField
+	FieldWeights        map[string]float64       // the weight of the matches in each secondary field
+	FieldDocLengths     map[string][]int         // the number of tokens of each document in FIELD_UNSTEMMED and the secondary fields
+	FieldAvgLengths     map[string]float64       // the average number of tokens of the documents in each field of FieldDocLengths
+	QuerySynonyms       *nlp.SynonymFilter       // the synonyms expanding the queries, see SetQuerySynonyms
+	SynonymWeight       float64                  // the weight of the query synonyms
+	Feedback            *FeedbackConfig          // the pseudo relevance feedback, nil if disabled, see SetFeedback
 }
 
+// FIELD_CONTENT is the field of the document content, indexed in SearchEngine.Index.
+const FIELD_CONTENT = "content"
+
 const SCORE_THRESHOLD = 0.5
 
 const BM25_WEIGHT = 0.5
 const TFIDF_WEIGHT = 0.5
 
+// MAX_EDIT_DISTANCE is the maximum edit distance of a fuzzy term (e.g. `term~2`).
+const MAX_EDIT_DISTANCE = 2
+
+// FUZZY_EDIT_PENALTY is the weight multiplier applied to a fuzzy expansion for each edit it is away from the query term.
+const FUZZY_EDIT_PENALTY = 0.5
+
+// queryTerm is a token of the query, and the weight applied to its contribution to the document scores.
+type queryTerm struct {
+	token  string
+	weight float64
+	field  string // the secondary field of the token, empty for the content field
+}
+
+func newQueryTerms(tokens []string, weight float64) []queryTerm {
+	return newFieldQueryTerms(tokens, weight, "")
+}
+
+func newFieldQueryTerms(tokens []string, weight float64, field string) []queryTerm {
+	terms := make([]queryTerm, 0, len(tokens))
+	for _, token := range tokens {
+		terms = append(terms, queryTerm{token: token, weight: weight, field: field})
+	}
+	return terms
+}
+
+// EngineOptions are the indexing options of NewSearchEngine, set before the documents are indexed, so that the
+// documents are indexed once.
+type EngineOptions struct {
+	// KeepPhraseStopwords indexes the positions of the stopwords, so that the phrase queries match them,
+	// see SetKeepPhraseStopwords and nlp.StopwordConfig.KeepInPhrases.
+	KeepPhraseStopwords bool
+	// Fields are the secondary fields indexed along with the content, see AddField.
+	Fields []FieldConfig
+	// IndexSynonyms are indexed along with the words of the content, see SetIndexSynonyms.
+	IndexSynonyms *nlp.SynonymMap
+	// QuerySynonyms expand the queries, with the weight SynonymWeight (SYNONYM_WEIGHT if zero), see SetQuerySynonyms.
+	QuerySynonyms *nlp.SynonymMap
+	SynonymWeight float64
+	// Feedback is the pseudo relevance feedback, see SetFeedback. An invalid configuration is logged and the
+	// feedback left disabled, so it should be checked first with FeedbackConfig.Validate.
+	Feedback *FeedbackConfig
+}
+
+/**
+ * Create a search engine, and index the given documents.
+ *
+ * The language of each document is detected, unless it is given, and the document is analyzed accordingly
+ * (e.g. with the stopwords and the stemmer of the language). The documents are copied, so the detected languages
+ * are set in SearchEngine.Documents and the given slice is left unchanged.
+ *
+ * @param docs The documents to index, the ID of each document must be its position in the slice
+ * @param analyzers The analyzer of each field, nlp.NewStandardAnalyzer() for the fields without one
+ * @param options The indexing options, at most one, the zero EngineOptions if none
+ * @return *SearchEngine
+ */
+func NewSearchEngine(docs []documents.Document, analyzers map[string]*nlp.Analyzer, options ...EngineOptions) *SearchEngine {
+	var opts EngineOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	se := &SearchEngine{Analyzers: analyzers, K1: 1.2, B: 0.75, Languages: make(map[string]int)}
+	se.KeepPhraseStopwords = opts.KeepPhraseStopwords
+	se.setSynonymOptions(opts)
+	if err := se.SetFeedback(opts.Feedback); err != nil {
+		log.Println("Error enabling the relevance feedback", err)
+	}
+	docs = append([]documents.Document(nil), docs...)
+	for i := range docs {
+		detectDocumentLanguage(&docs[i])
+		se.Languages[docs[i].Language]++
+	}
+	se.Documents = docs
+	se.buildIndex()
+	for _, field := range opts.Fields {
+		se.AddField(field.Name, field.Analyzer, field.Weight)
+	}
+	se.buildVocabulary()
+	return se
+}
+
 func (se *SearchEngine) SetK1(k1 float64) {
 	se.K1 = k1
 }
@@ -34,36 +139,212 @@ func (se *SearchEngine) SetB(b float64) {
 	se.B = b
 }
 
+/**
+ * Set the analyzer of a field.
+ * The documents must be indexed after the analyzer is set, so that the documents and the queries are analyzed the same way.
+ *
+ * @param field A field, e.g. FIELD_CONTENT
+ * @param analyzer The analyzer used to index and to search the field
+ */
+func (se *SearchEngine) SetAnalyzer(field string, analyzer *nlp.Analyzer) {
+	if se.Analyzers == nil {
+		se.Analyzers = make(map[string]*nlp.Analyzer)
+	}
+	se.Analyzers[field] = analyzer
+}
+
+/**
+ * Return the analyzer of a field, applied both to the documents at index time and to the queries at search time.
+ *
+ * @param field A field, e.g. FIELD_CONTENT
+ * @return *nlp.Analyzer the analyzer set for the field, nlp.NewStandardAnalyzer() if none
+ */
+func (se *SearchEngine) Analyzer(field string) *nlp.Analyzer {
+	if analyzer, ok := se.Analyzers[field]; ok {
+		return analyzer
+	}
+	return nlp.NewStandardAnalyzer()
+}
+
+/**
+ * Configure the stopwords removed from the content field, and index the documents again accordingly.
+ *
+ * @param config The stopword configuration, e.g. nlp.StopwordConfigFromEnv()
+ * @return error if the stopword file cannot be read or the language is unknown, the index is then left unchanged
+ */
+func (se *SearchEngine) SetStopwords(config nlp.StopwordConfig) error {
+	filter, err := config.Filter()
+	if err != nil {
+		return err
+	}
+	se.SetAnalyzer(FIELD_CONTENT, se.Analyzer(FIELD_CONTENT).WithStopwords(filter))
+	se.KeepPhraseStopwords = config.KeepInPhrases
+	se.Reindex()
+	return nil
+}
+
+/**
+ * Build the inverted index and the Bloom filter again from the documents, e.g. after the analyzer has changed.
+ */
+func (se *SearchEngine) Reindex() {
+	se.buildIndex()
+	for field := range se.FieldIndexes {
+		se.indexField(field, se.Analyzer(field))
+	}
+	se.buildVocabulary()
+}
+
+// buildIndex builds the inverted index, the Bloom filter, the positions, the term vectors and the document lengths of
+// the content field, and the unstemmed index if the content analyzer stems or removes stopwords.
+func (se *SearchEngine) buildIndex() {
+	analyzer := se.Analyzer(FIELD_CONTENT)
+	se.Index, se.Bloomfilter = make(InvertedIndex), newBloomFilter()
+	se.Unstemmed = nil
+	se.resetFieldLengths(FIELD_UNSTEMMED)
+	if analyzer.Stems() || analyzer.RemovesStopwords() {
+		se.Unstemmed = make(InvertedIndex)
+	}
+	se.Positions = make(PositionalIndex)
+	se.DocLengths = make([]int, 0, len(se.Documents))
+	se.TermVectors = make([]map[string]int, 0, len(se.Documents))
+	se.TotalDocCount, se.TotalDocLen, se.AvgDocLength = 0, 0, 0
+
+	for _, doc := range se.Documents {
+		se.indexDocument(analyzer, doc)
+	}
+}
+
+// indexDocument analyzes the content of a document once, adds its tokens to the inverted index and the Bloom filter,
+// their positions to the positional index, and its unstemmed tokens to the unstemmed index, and updates the term
+// vectors and the document lengths. The content is analyzed a second time for the positions if the phrases keep the stopwords.
+// It returns the tokens of the vocabulary, i.e. the unstemmed ones if there is an unstemmed index.
+func (se *SearchEngine) indexDocument(analyzer *nlp.Analyzer, doc documents.Document) []nlp.Token {
+	tokens, unstemmed := analyzer.AnalyzeLanguageUnstemmed(doc.Content, doc.Language)
+	addPostings(se.Index, doc.ID, tokens, se.Bloomfilter)
+	if se.KeepPhraseStopwords {
+		addPositions(se.Positions, doc.ID, se.phraseAnalyzer(analyzer).AnalyzeLanguage(doc.Content, doc.Language))
+	} else {
+		addPositions(se.Positions, doc.ID, tokens)
+	}
+
+	se.TermVectors = append(se.TermVectors, termVector(tokens))
+	se.DocLengths = append(se.DocLengths, len(tokens))
+	se.TotalDocLen += float64(len(tokens))
+	se.TotalDocCount++
+	se.AvgDocLength = se.TotalDocLen / se.TotalDocCount
+
+	if se.Unstemmed != nil {
+		addPostings(se.Unstemmed, doc.ID, unstemmed, nil)
+		se.addFieldLength(FIELD_UNSTEMMED, len(unstemmed))
+		return unstemmed
+	}
+	return tokens
+}
+
+// vocabularyField returns the field of the terms of the term dictionary, the spell corrector and the common terms:
+// FIELD_UNSTEMMED if the content analyzer stems or removes stopwords, FIELD_CONTENT otherwise.
+func (se *SearchEngine) vocabularyField() string {
+	if se.Unstemmed != nil {
+		return FIELD_UNSTEMMED
+	}
+	return FIELD_CONTENT
+}
+
+// docLength returns the number of analyzed tokens of a document, which is analyzed again if it has not been indexed
+// by the search engine, e.g. with BuildInvertedIndex.
+func (se *SearchEngine) docLength(docID int) float64 {
+	if docID < len(se.DocLengths) {
+		return float64(se.DocLengths[docID])
+	}
+	doc := se.Documents[docID]
+	return float64(len(se.Analyzer(FIELD_CONTENT).AnalyzeLanguage(doc.Content, doc.Language)))
+}
+
+// fieldLength returns the number of tokens of a document in a field, and the average number of tokens of the
+// documents in that field. The content lengths are used for the content field, and for the fields without lengths.
+func (se *SearchEngine) fieldLength(field string, docID int) (float64, float64) {
+	if lengths, ok := se.FieldDocLengths[field]; ok && docID < len(lengths) {
+		return float64(lengths[docID]), se.FieldAvgLengths[field]
+	}
+	return se.docLength(docID), se.AvgDocLength
+}
+
+// addFieldLength appends the number of tokens of the next document of a field, and updates the average of the field.
+func (se *SearchEngine) addFieldLength(field string, length int) {
+	if se.FieldDocLengths == nil {
+		se.FieldDocLengths = make(map[string][]int)
+		se.FieldAvgLengths = make(map[string]float64)
+	}
+	lengths := append(se.FieldDocLengths[field], length)
+	se.FieldDocLengths[field] = lengths
+	se.FieldAvgLengths[field] += (float64(length) - se.FieldAvgLengths[field]) / float64(len(lengths))
+}
+
+// resetFieldLengths removes the lengths of a field, before it is indexed again.
+func (se *SearchEngine) resetFieldLengths(field string) {
+	delete(se.FieldDocLengths, field)
+	delete(se.FieldAvgLengths, field)
+}
+
+// buildVocabulary builds the term dictionary and the spell corrector of the vocabulary field, so that the searches
+// only read them.
+func (se *SearchEngine) buildVocabulary() {
+	index := se.fieldIndex(se.vocabularyField())
+	se.Dictionary = NewTermDictionary(index)
+	se.Corrector = NewSpellCorrector(index, SPELL_MAX_DISTANCE)
+}
+
+// addVocabulary adds the tokens of a new document to the term dictionary and the spell corrector.
+func (se *SearchEngine) addVocabulary(tokens []nlp.Token) {
+	for _, token := range tokens {
+		if se.Dictionary != nil {
+			se.Dictionary.add(token.Term)
+		}
+		if se.Corrector != nil {
+			se.Corrector.add(token.Term, 1)
+		}
+	}
+}
+
+// SetAutoCorrect enables re-running a query with its spelling corrected, when the original query has no results.
+func (se *SearchEngine) SetAutoCorrect(autoCorrect bool) {
+	se.AutoCorrect = autoCorrect
+}
+
 /**
  * Add a new document to the search engine.
- * Update the inverted index and the bloom filter.
- * Internally checks if the total document length is not too large to avoid overflow.
+ * Detect its language if it is not given, and update the inverted index, the bloom filter and the document lengths.
  *
  * @param doc A document
  */
 func (se *SearchEngine) AddNewDocument(doc documents.Document) {
+	detectDocumentLanguage(&doc)
+	if se.Languages == nil {
+		se.Languages = make(map[string]int)
+	}
+	se.Languages[doc.Language]++
 	se.Documents = append(se.Documents, doc)
-	count := se.TotalDocCount
-	docLength := se.TotalDocLen
 
-	currentDocLength := float64(len(doc.Content))
-	// check if docLength + currentDocLength is too large to avoid overflow
-	if docLength+currentDocLength > math.MaxFloat64-100 {
-		return
+	// update the inverted index, the bloom filter, the positions, the term vectors, the document lengths and the vocabulary
+	if se.Index == nil {
+		se.Index, se.Bloomfilter = make(InvertedIndex), newBloomFilter()
 	}
+	if se.Positions == nil {
+		se.Positions = make(PositionalIndex)
+	}
+	se.addVocabulary(se.indexDocument(se.Analyzer(FIELD_CONTENT), doc))
 
-	// update the inverted index and the bloom filter
-	UpdateInvertedIndexWithDoc(se.Index, doc, true, se.Bloomfilter)
-
-	// increase the docLength
-	docLength += currentDocLength
-	count++
-	countF := float64(count)
+	// update the indexes of the secondary fields
+	for field, index := range se.FieldIndexes {
+		tokens := se.Analyzer(field).AnalyzeLanguage(doc.Content, doc.Language)
+		addPostings(index, doc.ID, tokens, nil)
+		se.addFieldLength(field, len(tokens))
+	}
 
-	// update the search engine
-	se.TotalDocCount = countF
-	se.TotalDocLen = docLength
-	se.AvgDocLength = docLength / countF
+	// update the substring index, if the substring search mode is enabled
+	if se.Substrings != nil {
+		se.Substrings.Put(strings.ToLower(doc.Content), doc.ID)
+	}
 }
 
 /**
@@ -74,19 +355,25 @@ func (se *SearchEngine) AddNewDocument(doc documents.Document) {
  * @return map[int]float64
  */
 func (se *SearchEngine) CalculateTFIDFScore(tokens []string) map[int]float64 {
+	return se.calculateTFIDFScore(newQueryTerms(tokens, 1.0))
+}
+
+func (se *SearchEngine) calculateTFIDFScore(terms []queryTerm) map[int]float64 {
 	scores := make(map[int]float64)
 
 	// iterate all tokens in the query
-	for _, token := range tokens {
-		if docSet, ok := se.Index[token]; ok {
-			idf := math.Log(float64(len(se.Documents)) / float64(len(docSet)))
+	for _, term := range terms {
+		token := term.token
+		if docSet, ok := se.fieldIndex(term.field)[token]; ok {
+			frequencies := termFrequencies(docSet)
+			idf := math.Log(float64(len(se.Documents)) / float64(len(frequencies)))
 
 			// iterate all document that contains the token
-			for _, docID := range docSet {
-				tf := float64(strings.Count(strings.ToLower(se.Documents[docID].Content), token))
+			for docID, frequency := range frequencies {
+				tf := float64(frequency)
 
 				// TF-IDF score * weight
-				scores[docID] += tf * idf * TFIDF_WEIGHT
+				scores[docID] += tf * idf * TFIDF_WEIGHT * term.weight
 			}
 		}
 	}
@@ -102,24 +389,31 @@ func (se *SearchEngine) CalculateTFIDFScore(tokens []string) map[int]float64 {
  * @return map[int]float64
  */
 func (se *SearchEngine) CalculateBM25Score(tokens []string) map[int]float64 {
+	return se.calculateBM25Score(newQueryTerms(tokens, 1.0))
+}
+
+func (se *SearchEngine) calculateBM25Score(terms []queryTerm) map[int]float64 {
 	scores := make(map[int]float64)
 
 	// iterate all tokens in the query
-	for _, token := range tokens {
-		if docSet, ok := se.Index[token]; ok {
-			idf := math.Log(float64(len(se.Documents)-len(docSet))+0.5) / (float64(len(docSet)) + 0.5)
+	for _, term := range terms {
+		token := term.token
+		if docSet, ok := se.fieldIndex(term.field)[token]; ok {
+			frequencies := termFrequencies(docSet)
+			idf := math.Log(float64(len(se.Documents)-len(frequencies))+0.5) / (float64(len(frequencies)) + 0.5)
 
 			// iterate all document that contains the token
-			for _, docID := range docSet {
-				tf := float64(strings.Count(strings.ToLower(se.Documents[docID].Content), token))
-				dl := float64(len(strings.Fields(strings.ToLower(se.Documents[docID].Content))))
-				numerator := (se.K1 + 1) * tf * (se.K1 + 1) / (tf + se.K1*(1.0-se.B+se.B*dl/se.AvgDocLength))
-				denominator := tf + se.K1*(1.0-se.B+se.B*dl/se.AvgDocLength)
+			for docID, frequency := range frequencies {
+				tf := float64(frequency)
+				// the length of the document in the field of the token, relative to the other documents in that field
+				dl, avgdl := se.fieldLength(term.field, docID)
+				numerator := (se.K1 + 1) * tf * (se.K1 + 1) / (tf + se.K1*(1.0-se.B+se.B*dl/avgdl))
+				denominator := tf + se.K1*(1.0-se.B+se.B*dl/avgdl)
 
 				// BM25 score
 				score := idf * numerator / denominator
 				// apply weight to the score
-				scores[docID] += score * BM25_WEIGHT
+				scores[docID] += score * BM25_WEIGHT * term.weight
 			}
 		}
 	}
@@ -127,11 +421,98 @@ func (se *SearchEngine) CalculateBM25Score(tokens []string) map[int]float64 {
 	return scores
 }
 
+// scoreTerms combines the TF-IDF and the BM25 scores of the query terms in each document.
+func (se *SearchEngine) scoreTerms(terms []queryTerm) map[int]float64 {
+	// ranking with TF-IDF
+	scores := se.calculateTFIDFScore(terms)
+	// ranking with BM25
+	scoresBm25 := se.calculateBM25Score(terms)
+
+	// combine the scores from TF-IDF and BM25 for weighted ranking
+	for docID, score := range scoresBm25 {
+		scores[docID] += score
+	}
+	return scores
+}
+
+/**
+ * Return the term dictionary of the inverted index, with the terms as they are written if the content is stemmed
+ * (see FIELD_UNSTEMMED), so that the patterns match the words rather than their stems.
+ * The dictionary is built by NewSearchEngine and Reindex, and updated by AddNewDocument, so that the searches never
+ * modify the search engine. Without it, e.g. for a search engine not created with NewSearchEngine, a dictionary is
+ * built for the caller only.
+ *
+ * @return *TermDictionary
+ */
+func (se *SearchEngine) TermDictionary() *TermDictionary {
+	if se.Dictionary == nil {
+		return NewTermDictionary(se.fieldIndex(se.vocabularyField()))
+	}
+	return se.Dictionary
+}
+
+/**
+ * Return the spell corrector built from the vocabulary of the inverted index, which suggests the words as they are
+ * written rather than their stems. As the term dictionary, it is kept up to date by NewSearchEngine, AddNewDocument and Reindex.
+ *
+ * @return *SpellCorrector
+ */
+func (se *SearchEngine) SpellCorrector() *SpellCorrector {
+	if se.Corrector == nil {
+		return NewSpellCorrector(se.fieldIndex(se.vocabularyField()), SPELL_MAX_DISTANCE)
+	}
+	return se.Corrector
+}
+
+/**
+ * Suggest a spelling correction of the user input query ("Did you mean ...?").
+ * Every word of the free text that is not in the index vocabulary is replaced with its best suggestion.
+ * Wildcard patterns and fuzzy terms are kept as they are, and so are the words the content analyzer drops
+ * (e.g. the stopwords), which cannot match any index term.
+ *
+ * @param query A search query
+ * @return (string, bool) the corrected query, and whether any word has been corrected
+ */
+func (se *SearchEngine) DidYouMean(query string) (string, bool) {
+	corrector := se.SpellCorrector()
+	analyzer := se.Analyzer(FIELD_CONTENT)
+
+	corrected := false
+	words := strings.Fields(query)
+	for i, word := range words {
+		if isWildcardPattern(word) || fuzzyTermPattern.MatchString(word) || languageFilterPattern.MatchString(word) {
+			continue
+		}
+
+		// keep the punctuation around the word, e.g. the question mark of "hobit?"
+		start := strings.IndexFunc(word, isWordRune)
+		end := strings.LastIndexFunc(word, isWordRune)
+		if start < 0 {
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(word[end:])
+		end += size
+
+		bare := strings.ToLower(word[start:end])
+		if corrector.Contains(bare) || len(se.analyzeQueryText(analyzer, bare, nil)) == 0 {
+			continue
+		}
+		if suggestions := corrector.Suggest(bare, 1); len(suggestions) > 0 {
+			words[i] = word[:start] + suggestions[0].Term + word[end:]
+			corrected = true
+		}
+	}
+
+	return strings.Join(words, " "), corrected
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
 /**
  * Search for documents based on the user input query.
- * Remove stopwords from the query, tokenize the query, and filter out the tokens that are not in the Bloom filter.
- * Calculate the TF-IDF score and BM25 score for each document.
- * Combine the scores with a weighted sum, and return the top N results.
+ * Errors (e.g. a too broad wildcard pattern) are swallowed, and result in empty results.
  *
  * @param query A search query
  * @param limit The maximum number of results to return
@@ -139,41 +520,177 @@ func (se *SearchEngine) CalculateBM25Score(tokens []string) map[int]float64 {
  * @return []documents.Document
  */
 func (se *SearchEngine) Search(query string, limit int) []documents.Document {
-	// remove stopwords from the query
-	cleanedQuery := removeStopwords(query)
+	results, err := se.SearchQuery(query, limit)
+	if err != nil {
+		return []documents.Document{}
+	}
+	return results
+}
 
-	// tokenize the query
-	// tokens := strings.Fields(strings.ToLower(cleanedQuery))
-	tokens := nlp.Tokenize_Query(strings.ToLower(cleanedQuery))
+/**
+ * Search for documents based on the user input query.
+ * Expand the prefix and wildcard patterns of the query (e.g. `hobb*`, `*ness`, `b?g`) into the matching index terms,
+ * and the fuzzy terms (e.g. `hobit~1`) into the index terms within the edit distance, weighted down for each edit.
+ * The patterns and the fuzzy terms match the words as they are written, even if the content is stemmed (see FIELD_UNSTEMMED).
+ * The Hangul prefixes and fuzzy terms are matched by their jamo (e.g. `아잉*` and `아이우~1` match `아이유`),
+ * and the Hangul initial consonants (e.g. `ㅇㅇㅇ`) are expanded into the index terms they start.
+ * Analyze the rest of the query with the analyzer of the content field (see Analyzer),
+ * and filter out the tokens that are not in the Bloom filter.
+ * If the query has `lang:` filters (e.g. `lang:ko`), only the documents written in one of the languages are returned,
+ * and the query is analyzed in these languages.
+ * Otherwise the query is analyzed in each language of the documents, so that it matches the stems of every language.
+ * The query is expanded with the query synonyms, weighted with SynonymWeight (see SetQuerySynonyms).
+ * The phrases between double quotes (e.g. `"hobbit hole"`) only match the documents containing their words
+ * next to each other, with their stopwords if KeepPhraseStopwords is set, and score them higher.
+ * Calculate the TF-IDF score and BM25 score for each document.
+ * Combine the scores with a weighted sum, and return the top N results.
+ * If the pseudo relevance feedback is enabled, the query is expanded with the terms of its top documents and run again
+ * (see SetFeedback).
+ * If AutoCorrect is enabled and there is no result, the query is re-run with its spelling corrected (see DidYouMean).
+ *
+ * @param query A search query
+ * @param limit The maximum number of results to return
+ *
+ * @return ([]documents.Document, error) the results,
+ *         ErrInvalidQuery if the query syntax is invalid, ErrPatternTooBroad if a pattern matches more than MAX_EXPANSIONS terms
+ */
+func (se *SearchEngine) SearchQuery(query string, limit int) ([]documents.Document, error) {
+	return se.searchWithCorrection(query, limit, searchOptions{})
+}
 
-	// Check if all tokens are not in the Bloom filter
-	// Filter out present tokens only
-	allTokensNotPresent := true
-	presentTokens := make([]string, 0)
-	for _, token := range tokens {
-		present, _ := se.Bloomfilter.Test([]byte(token))
-		if present {
-			allTokensNotPresent = false
-			presentTokens = append(presentTokens, token)
+// searchOptions are the options of a query changing how it is matched and scored.
+type searchOptions struct {
+	commonTermsCutoff float64 // scores the common terms as CommonTermsSearch if > 0
+	phonetic          bool    // matches the query in FIELD_PHONETIC too, see PhoneticSearch
+}
+
+// searchWithCorrection runs a query, and runs it again with its spelling corrected if it has no result and
+// AutoCorrect is enabled.
+func (se *SearchEngine) searchWithCorrection(query string, limit int, options searchOptions) ([]documents.Document, error) {
+	results, err := se.searchQuery(query, limit, options)
+	if err != nil || len(results) > 0 || !se.AutoCorrect {
+		return results, err
+	}
+
+	if corrected, ok := se.DidYouMean(query); ok {
+		return se.searchQuery(corrected, limit, options)
+	}
+	return results, nil
+}
+
+// searchQuery runs a query with its options, see SearchQuery.
+func (se *SearchEngine) searchQuery(query string, limit int, options searchOptions) ([]documents.Document, error) {
+	parsed, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	analyzer := se.Analyzer(FIELD_CONTENT)
+	presentTokens := make([]queryTerm, 0)
+	// the expansions are terms of the vocabulary, matched in its field
+	vocabularyField := se.vocabularyField()
+
+	// expand the wildcard patterns into the matching terms of the index
+	for _, pattern := range parsed.wildcards {
+		expansions, err := se.TermDictionary().ExpandWildcard(analyzer.Normalize(pattern), MAX_EXPANSIONS)
+		if err != nil {
+			return nil, err
+		}
+		presentTokens = append(presentTokens, newFieldQueryTerms(expansions, 1.0, vocabularyField)...)
+	}
+
+	// expand the initial consonants into the Hangul terms of the index, e.g. ㅇㅇㅇ into 아이유
+	for _, pattern := range parsed.chosung {
+		expansions, err := se.TermDictionary().ExpandChosung(pattern, MAX_EXPANSIONS)
+		if err != nil {
+			return nil, err
+		}
+		presentTokens = append(presentTokens, newFieldQueryTerms(expansions, 1.0, vocabularyField)...)
+	}
+
+	// expand the fuzzy terms into the close terms of the index, with a penalty for each edit
+	for _, fuzzy := range parsed.fuzzy {
+		for _, expansion := range se.TermDictionary().ExpandFuzzy(analyzer.Normalize(fuzzy.term), fuzzy.distance, MAX_EXPANSIONS) {
+			weight := math.Pow(FUZZY_EDIT_PENALTY, float64(expansion.Distance))
+			presentTokens = append(presentTokens, queryTerm{token: expansion.Term, weight: weight, field: vocabularyField})
+		}
+	}
+
+	if strings.TrimSpace(parsed.text) != "" {
+		// analyze the query the same way as the documents, or as the vocabulary to match the common terms with their
+		// stopwords
+		textAnalyzer, textField := analyzer, ""
+		if options.commonTermsCutoff > 0 && vocabularyField != FIELD_CONTENT {
+			textAnalyzer, textField = analyzer.Unstemmed(), vocabularyField
+		}
+		// Filter out present tokens only
+		for _, term := range se.expandQueryText(textAnalyzer, parsed.text, parsed.languages) {
+			present, _ := se.Bloomfilter.Test([]byte(term.token))
+			if textField != "" {
+				_, present = se.fieldIndex(textField)[term.token]
+				term.field = textField
+			}
+			if present {
+				presentTokens = append(presentTokens, term)
+			}
+		}
+	}
+
+	// match the query in the secondary fields, e.g. the subword pieces of its words
+	presentTokens = append(presentTokens, se.fieldQueryTerms(parsed, options.phonetic)...)
+
+	// match the phrases, and score their terms as the rest of the query
+	phrases := make([]map[int]bool, 0, len(parsed.phrases))
+	for _, phrase := range parsed.phrases {
+		if matches, ok := se.matchPhrase(analyzer, phrase, parsed.languages); ok {
+			phrases = append(phrases, matches)
+		}
+		for _, term := range se.analyzeQueryText(analyzer, phrase, parsed.languages) {
+			if present, _ := se.Bloomfilter.Test([]byte(term)); present {
+				presentTokens = append(presentTokens, queryTerm{token: term, weight: 1.0})
+			}
 		}
 	}
 
 	// if all tokens are not in the Bloom filter, return empty results
-	if allTokensNotPresent {
-		return []documents.Document{}
+	if len(presentTokens) == 0 && len(phrases) == 0 {
+		return []documents.Document{}, nil
 	}
 
-	// ranking with TF-IDF
-	scores := se.CalculateTFIDFScore(presentTokens)
-	// ranking with BM25
-	scoresBm25 := se.CalculateBM25Score(presentTokens)
+	score := func(terms []queryTerm) map[int]float64 {
+		var scores map[int]float64
+		if options.commonTermsCutoff > 0 {
+			scores = se.scoreCommonTerms(terms, options.commonTermsCutoff)
+		} else {
+			scores = se.scoreTerms(terms)
+		}
+		se.scorePhrases(scores, phrases)
+		se.filterLanguages(scores, parsed.languages)
+		return scores
+	}
+	scores := score(presentTokens)
 
-	// combine the scores from TF-IDF and BM25 for weighted ranking
-	for docID, score := range scoresBm25 {
-		scores[docID] += score
+	// run the query again, expanded with the terms of its top documents
+	if se.Feedback != nil {
+		if expanded := se.expandWithFeedback(scores, presentTokens); len(expanded) > 0 {
+			scores = score(expanded)
+		}
 	}
 
-	var results []documents.Document
+	return se.rankResults(scores, limit), nil
+}
+
+/**
+ * Turn the document scores into the search results.
+ * Filter out the documents with a score less than SCORE_THRESHOLD, and return the top N documents sorted by score.
+ *
+ * @param scores The score of each document
+ * @param limit The maximum number of results to return
+ *
+ * @return []documents.Document
+ */
+func (se *SearchEngine) rankResults(scores map[int]float64, limit int) []documents.Document {
+	results := make([]documents.Document, 0)
 	for docID, score := range scores {
 		// filter out the results with score less than SCORE_THRESHOLD
 		if score < SCORE_THRESHOLD {
@@ -183,9 +700,10 @@ func (se *SearchEngine) Search(query string, limit int) []documents.Document {
 		results = append(
 			results,
 			documents.Document{
-				ID:      docID,
-				Content: se.Documents[docID].Content,
-				Score:   score,
+				ID:       docID,
+				Content:  se.Documents[docID].Content,
+				Score:    score,
+				Language: se.Documents[docID].Language,
 			},
 		)
 	}
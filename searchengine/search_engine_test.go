@@ -0,0 +1,224 @@
+package searchengine
+
+import (
+	"testing"
+
+	documents "go4search/documents"
+	nlp "go4search/nlp"
+)
+
+// newTestAnalyzer returns an analyzer that does not need the pretrained tokenizer.
+func newTestAnalyzer() *nlp.Analyzer {
+	return nlp.NewAnalyzer([]nlp.CharFilter{nlp.LowercaseFilter}, nlp.WhitespaceTokenizer{}, nlp.NewStopwordFilter("english"))
+}
+
+// fillerContents are unrelated documents padding the small test corpora: SCORE_THRESHOLD is absolute, and the score
+// of a term grows with the number of documents without it.
+var fillerContents = []string{
+	"Happy families are all alike",
+	"All children except one grow up",
+	"Mother left home today",
+	"Someone must have slandered Josef K",
+	"Many years later as he faced his firing squad",
+	"Mrs Dalloway said she would buy flowers herself",
+}
+
+// paddedCorpus returns the documents with the given contents followed by the filler documents, numbered in order.
+func paddedCorpus(contents ...string) []documents.Document {
+	docs := make([]documents.Document, 0, len(contents)+len(fillerContents))
+	for _, content := range append(contents, fillerContents...) {
+		docs = append(docs, documents.Document{ID: len(docs), Content: content})
+	}
+	return docs
+}
+
+// testEngine describes a search engine over a small test corpus, built by build.
+type testEngine struct {
+	analyzer  *nlp.Analyzer  // the analyzer of FIELD_CONTENT, newTestAnalyzer() if nil
+	contents  []string       // the contents of the documents, followed by fillerContents
+	languages map[int]string // the languages given to some documents, the others are detected
+	options   EngineOptions
+}
+
+// build indexes the corpus of the test engine.
+func (e testEngine) build() *SearchEngine {
+	analyzer := e.analyzer
+	if analyzer == nil {
+		analyzer = newTestAnalyzer()
+	}
+	docs := paddedCorpus(e.contents...)
+	for docID, language := range e.languages {
+		docs[docID].Language = language
+	}
+	return NewSearchEngine(docs, map[string]*nlp.Analyzer{FIELD_CONTENT: analyzer}, e.options)
+}
+
+func TestSearchWithAnalyzer(t *testing.T) {
+	docs := paddedCorpus("In a hole in the ground there lived a hobbit", "The hobbit loved the hobbit hole", "I have a dream", "To be or not to be that is the question")
+	se := NewSearchEngine(docs, map[string]*nlp.Analyzer{FIELD_CONTENT: newTestAnalyzer()})
+
+	// the stopwords are removed at index time
+	if _, ok := se.Index["the"]; ok {
+		t.Error("Expected the stopword 'the' not to be indexed")
+	}
+	if !equalSlice(se.Index["hobbit"], []int{0, 1, 1}) {
+		t.Errorf("Expected the postings [0 1 1] for 'hobbit', got %v", se.Index["hobbit"])
+	}
+
+	// and the query is analyzed the same way
+	results, err := se.SearchQuery("The HOBBIT", 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// document 1 contains hobbit twice
+	if len(results) != 2 || results[0].ID != 1 || results[1].ID != 0 {
+		t.Errorf("Expected documents 1 and 0, got %v", results)
+	}
+
+	// the documents added later are analyzed with the same analyzer
+	se.AddNewDocument(documents.Document{ID: len(docs), Content: "Dream of THE ground"})
+	if _, ok := se.Index["the"]; ok {
+		t.Error("Expected the stopword 'the' not to be indexed")
+	}
+	if results := se.Search("ground", 10); len(results) != 2 {
+		t.Errorf("Expected documents 0 and 6, got %v", results)
+	}
+}
+
+func TestDocumentLengths(t *testing.T) {
+	docs := []documents.Document{
+		{ID: 0, Content: "In a hole in the ground there lived a hobbit"},
+		{ID: 1, Content: "The hobbit, the HOBBIT"},
+	}
+	se := NewSearchEngine(docs, map[string]*nlp.Analyzer{FIELD_CONTENT: newTestAnalyzer()})
+
+	// the lengths are the numbers of analyzed tokens, without the stopwords, rather than bytes or raw words
+	if !equalSlice(se.DocLengths, []int{4, 2}) || se.TotalDocLen != 6 || se.AvgDocLength != 3 {
+		t.Errorf("Expected the lengths [4 2] and the average 3, got %v and %v", se.DocLengths, se.AvgDocLength)
+	}
+
+	se.AddNewDocument(documents.Document{ID: 2, Content: "the dragon"})
+	if !equalSlice(se.DocLengths, []int{4, 2, 1}) || se.TotalDocCount != 3 || se.AvgDocLength != 7.0/3 {
+		t.Errorf("Expected the lengths [4 2 1] and the average 7/3, got %v and %v", se.DocLengths, se.AvgDocLength)
+	}
+
+	// the lengths follow the analyzer
+	se.SetAnalyzer(FIELD_CONTENT, nlp.NewWhitespaceAnalyzer())
+	se.Reindex()
+	if !equalSlice(se.DocLengths, []int{10, 4, 2}) || se.AvgDocLength != 16.0/3 {
+		t.Errorf("Expected the lengths [10 4 2], got %v and %v", se.DocLengths, se.AvgDocLength)
+	}
+}
+
+func TestAnalyzerDefault(t *testing.T) {
+	se := SearchEngine{}
+	if _, ok := se.Analyzer(FIELD_CONTENT).Tokenizer.(*nlp.KoreanTokenizer); !ok {
+		t.Errorf("Expected the standard analyzer by default, got %v", se.Analyzer(FIELD_CONTENT))
+	}
+
+	analyzer := newTestAnalyzer()
+	se.SetAnalyzer(FIELD_CONTENT, analyzer)
+	if se.Analyzer(FIELD_CONTENT) != analyzer {
+		t.Error("Expected the analyzer set for the content field")
+	}
+}
+
+func TestSearchWithStemming(t *testing.T) {
+	docs := paddedCorpus("I have a dream", "As Gregor Samsa awoke one morning from uneasy dreams")
+	analyzer := nlp.NewAnalyzer([]nlp.CharFilter{nlp.LowercaseFilter}, nlp.WhitespaceTokenizer{}, nlp.NewStemmerFilter("english"))
+	se := NewSearchEngine(docs, map[string]*nlp.Analyzer{FIELD_CONTENT: analyzer})
+
+	// dreams and dream are both indexed as dream
+	for _, query := range []string{"dreams", "dream", "Dreaming"} {
+		if results := se.Search(query, 10); len(results) != 2 {
+			t.Errorf("%s: expected documents 0 and 1, got %v", query, results)
+		}
+	}
+}
+
+func TestSearchStandardAnalyzerUnstemmed(t *testing.T) {
+	if err := nlp.Load_Tokenizer(nlp.TokenizerConfig{Path: "../nlp/testdata/tokenizer.json"}); err != nil {
+		t.Fatal(err)
+	}
+	docs := paddedCorpus("Hobbits love happiness", "The hobbit hole in the ground", "A dream")
+	se := NewSearchEngine(docs, nil)
+
+	// the content is stemmed, but the patterns match the words as they are written
+	if !equalSlice(se.Index["happi"], []int{0}) || !equalSlice(se.Unstemmed["happiness"], []int{0}) {
+		t.Fatalf("Expected happiness to be indexed stemmed and unstemmed, got %v and %v", se.Index, se.Unstemmed)
+	}
+	for query, expected := range map[string]int{"hobb*": 2, "*ness": 1, "happines*": 1, "hobbits": 2} {
+		if results := se.Search(query, 10); len(results) != expected {
+			t.Errorf("%s: expected %d documents, got %v", query, expected, results)
+		}
+	}
+
+	// the suggestions are words, not stems (the misspelled word is made of pieces of the test vocabulary)
+	if corrected, ok := se.DidYouMean("happinesss"); !ok || corrected != "happiness" {
+		t.Errorf("Expected happiness, got %s", corrected)
+	}
+	if _, ok := se.DidYouMean("hobbits"); ok {
+		t.Error("Expected hobbits to be in the vocabulary")
+	}
+
+	// the documents added later are indexed unstemmed too
+	se.AddNewDocument(documents.Document{ID: len(docs), Content: "Hobbits and their dreams"})
+	if results := se.Search("dreams*", 10); len(results) != 1 || results[0].ID != len(docs) {
+		t.Errorf("Expected the added document, got %v", results)
+	}
+}
+
+func TestSearchKorean(t *testing.T) {
+	docs := paddedCorpus("아이유(IU, 본명: 이지은)는 대한민국의 가수이자 배우이다.", "배우로 활동할 때도 예명을 사용한다.")
+	se := NewSearchEngine(docs, map[string]*nlp.Analyzer{FIELD_CONTENT: nlp.NewKoreanAnalyzer()})
+
+	if results := se.Search("아이유", 10); len(results) != 1 || results[0].ID != 0 {
+		t.Errorf("Expected document 0, got %v", results)
+	}
+	if results := se.Search("배우는", 10); len(results) != 2 {
+		t.Errorf("Expected documents 0 and 1, got %v", results)
+	}
+}
+
+func TestSearchKoreanJamo(t *testing.T) {
+	docs := paddedCorpus("아이유는 대한민국의 가수이다.", "아이폰이 출시되었다.")
+	se := NewSearchEngine(docs, map[string]*nlp.Analyzer{FIELD_CONTENT: nlp.NewKoreanAnalyzer()})
+
+	// the initial consonants, typed in full or as a prefix
+	if results := se.Search("ㅇㅇㅇ", 10); len(results) != 1 || results[0].ID != 0 {
+		t.Errorf("Expected document 0, got %v", results)
+	}
+	if results := se.Search("ㅇㅇ*", 10); len(results) != 2 {
+		t.Errorf("Expected documents 0 and 1, got %v", results)
+	}
+	// a wrong jamo, and the syllable being typed
+	for _, query := range []string{"아이우~1", "아잉*", "아이ㅇ*"} {
+		if results := se.Search(query, 10); len(results) != 1 || results[0].ID != 0 {
+			t.Errorf("%s: expected document 0, got %v", query, results)
+		}
+	}
+}
+
+func TestSearchCJK(t *testing.T) {
+	docs := paddedCorpus("我爱北京天安门", "東京都に住んでいます")
+	se := NewSearchEngine(docs, map[string]*nlp.Analyzer{FIELD_CONTENT: nlp.NewCJKAnalyzer(false)})
+
+	if results := se.Search("北京", 10); len(results) != 1 || results[0].ID != 0 {
+		t.Errorf("Expected document 0, got %v", results)
+	}
+	if results := se.Search("京都", 10); len(results) != 1 || results[0].ID != 1 {
+		t.Errorf("Expected document 1, got %v", results)
+	}
+}
+
+func TestSearchWithNormalization(t *testing.T) {
+	docs := paddedCorpus("Un café au lait", "Die Straße nach İstanbul")
+	analyzer := nlp.NewAnalyzer(nlp.NormalizationFilters(true), nlp.WhitespaceTokenizer{})
+	se := NewSearchEngine(docs, map[string]*nlp.Analyzer{FIELD_CONTENT: analyzer})
+
+	for query, docID := range map[string]int{"CAFE": 0, "ｃａｆé": 0, "STRASSE": 1, "istanbul": 1} {
+		if results := se.Search(query, 10); len(results) != 1 || results[0].ID != docID {
+			t.Errorf("%s: expected document %d, got %v", query, docID, results)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package searchengine
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	nlp "go4search/nlp"
+)
+
+// SPELL_MAX_DISTANCE is the maximum edit distance between a misspelled word and its suggestions.
+const SPELL_MAX_DISTANCE = 2
+
+// Suggestion is a correction candidate for a misspelled word.
+type Suggestion struct {
+	Term      string
+	Distance  int // edit distance to the misspelled word
+	Frequency int // number of occurrences of the term in the index
+}
+
+// SpellCorrector suggests corrections for misspelled words from the vocabulary of an inverted index,
+// using the symmetric delete algorithm: the vocabulary terms and the misspelled word are both reduced to
+// their variants with up to maxDistance deleted runes, and the terms sharing a variant with the word are
+// the correction candidates. Candidates are ranked by edit distance, then by term frequency.
+type SpellCorrector struct {
+	maxDistance int
+	frequencies map[string]int      // term -> number of occurrences in the index
+	deletes     map[string][]string // delete variant -> terms having this variant
+}
+
+/**
+ * Build a spell corrector from the words of the inverted index.
+ * Subword pieces (e.g. `##bit`) and tokens without any letter are not part of the vocabulary.
+ *
+ * @param index An inverted index
+ * @param maxDistance The maximum edit distance of the suggestions
+ * @return *SpellCorrector
+ */
+func NewSpellCorrector(index InvertedIndex, maxDistance int) *SpellCorrector {
+	sc := &SpellCorrector{
+		maxDistance: maxDistance,
+		frequencies: make(map[string]int),
+		deletes:     make(map[string][]string),
+	}
+	for term, docIDs := range index {
+		sc.add(term, len(docIDs))
+	}
+	return sc
+}
+
+// add counts new occurrences of an index term, and adds it to the vocabulary if it is a new word.
+func (sc *SpellCorrector) add(term string, occurrences int) {
+	if !isVocabularyWord(term) {
+		return
+	}
+	if _, ok := sc.frequencies[term]; !ok {
+		for variant := range deleteVariants(term, sc.maxDistance) {
+			sc.deletes[variant] = append(sc.deletes[variant], term)
+		}
+	}
+	sc.frequencies[term] += occurrences
+}
+
+// VocabularySize returns the number of index terms the corrector knows.
+func (sc *SpellCorrector) VocabularySize() int {
+	return len(sc.frequencies)
+}
+
+// Contains reports whether the word is part of the vocabulary.
+func (sc *SpellCorrector) Contains(word string) bool {
+	_, ok := sc.frequencies[word]
+	return ok
+}
+
+/**
+ * Return the vocabulary terms within the maximum edit distance of the word.
+ * The closest terms come first, and terms at the same distance are ranked by frequency.
+ *
+ * @param word A (possibly misspelled) word
+ * @param limit The maximum number of suggestions to return. Use <=0 to get all
+ * @return []Suggestion
+ */
+func (sc *SpellCorrector) Suggest(word string, limit int) []Suggestion {
+	word = strings.ToLower(word)
+	suggestions := make([]Suggestion, 0)
+	seen := make(map[string]bool)
+
+	for variant := range deleteVariants(word, sc.maxDistance) {
+		for _, term := range sc.deletes[variant] {
+			if seen[term] {
+				continue
+			}
+			seen[term] = true
+
+			// sharing a delete variant does not bound the edit distance, so it has to be verified
+			distance := nlp.Calculate_Levenshtein_Distance(word, term)
+			if distance > sc.maxDistance {
+				continue
+			}
+			suggestions = append(suggestions, Suggestion{Term: term, Distance: distance, Frequency: sc.frequencies[term]})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Distance != suggestions[j].Distance {
+			return suggestions[i].Distance < suggestions[j].Distance
+		}
+		if suggestions[i].Frequency != suggestions[j].Frequency {
+			return suggestions[i].Frequency > suggestions[j].Frequency
+		}
+		return suggestions[i].Term < suggestions[j].Term
+	})
+
+	if limit > 0 && len(suggestions) > limit {
+		return suggestions[:limit]
+	}
+	return suggestions
+}
+
+// deleteVariants returns the word and all the strings obtained by deleting up to maxDistance runes from it.
+func deleteVariants(word string, maxDistance int) map[string]bool {
+	variants := map[string]bool{word: true}
+	frontier := []string{word}
+	for distance := 0; distance < maxDistance; distance++ {
+		next := make([]string, 0)
+		for _, variant := range frontier {
+			runes := []rune(variant)
+			for i := range runes {
+				deleted := string(runes[:i]) + string(runes[i+1:])
+				if !variants[deleted] {
+					variants[deleted] = true
+					next = append(next, deleted)
+				}
+			}
+		}
+		frontier = next
+	}
+	return variants
+}
+
+func isVocabularyWord(term string) bool {
+	if strings.HasPrefix(term, "##") {
+		return false
+	}
+	return strings.IndexFunc(term, unicode.IsLetter) >= 0
+}
@@ -0,0 +1,89 @@
+package searchengine
+
+import (
+	"testing"
+
+	documents "go4search/documents"
+	nlp "go4search/nlp"
+)
+
+func TestSpellCorrectorSuggest(t *testing.T) {
+	docs := []documents.Document{
+		{ID: 0, Content: "I have a dream that one day this nation will rise up"},
+		{ID: 1, Content: "As Gregor Samsa awoke one morning from uneasy dreams"},
+		{ID: 2, Content: "a dream within a dream"},
+	}
+	index, _ := BuildInvertedIndex(docs, false)
+	corrector := NewSpellCorrector(index, SPELL_MAX_DISTANCE)
+
+	suggestions := corrector.Suggest("drem", 0)
+	if len(suggestions) < 2 {
+		t.Fatalf("Expected at least 2 suggestions, got %v", suggestions)
+	}
+	// "dream" is both the closest and the most frequent term
+	if suggestions[0].Term != "dream" || suggestions[0].Distance != 1 || suggestions[0].Frequency != 3 {
+		t.Errorf("Unexpected best suggestion %v", suggestions[0])
+	}
+	if suggestions[1].Term != "dreams" || suggestions[1].Distance != 2 {
+		t.Errorf("Unexpected second suggestion %v", suggestions[1])
+	}
+
+	if suggestions := corrector.Suggest("xyzzy", 0); len(suggestions) != 0 {
+		t.Errorf("Expected no suggestion, got %v", suggestions)
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	docs := []documents.Document{
+		{ID: 0, Content: "In a hole in the ground there lived a hobbit"},
+		{ID: 1, Content: "I have a dream that one day this nation will rise up"},
+	}
+	index, sbf := BuildInvertedIndex(docs, false)
+	analyzers := map[string]*nlp.Analyzer{FIELD_CONTENT: nlp.NewWhitespaceAnalyzer()}
+	se := SearchEngine{Index: index, Documents: docs, Bloomfilter: sbf, Analyzers: analyzers}
+
+	corrected, ok := se.DidYouMean("Hobit grond?")
+	if !ok || corrected != "hobbit ground?" {
+		t.Errorf("Expected \"hobbit ground?\", got %q (%v)", corrected, ok)
+	}
+
+	if _, ok := se.DidYouMean("hobbit hole"); ok {
+		t.Errorf("Expected no correction for a correctly spelled query")
+	}
+}
+
+func TestDidYouMeanStopwords(t *testing.T) {
+	docs := paddedCorpus("In a hole in the ground there lived a hobbit", "I have a dream that one day this nation will rise up")
+	analyzer := nlp.NewWhitespaceAnalyzer().WithStopwords(nlp.NewStopwordFilter("english"))
+	se := NewSearchEngine(docs, map[string]*nlp.Analyzer{FIELD_CONTENT: analyzer})
+
+	// the stopwords are not indexed, but they are not misspelled words either
+	corrected, ok := se.DidYouMean("where is the hobit")
+	if !ok || corrected != "where is the hobbit" {
+		t.Errorf("Expected \"where is the hobbit\", got %q (%v)", corrected, ok)
+	}
+	if corrected, ok := se.DidYouMean("where is the hobbit"); ok {
+		t.Errorf("Expected no correction, got %q", corrected)
+	}
+}
+
+func TestSpellCorrectorUpdates(t *testing.T) {
+	docs := []documents.Document{
+		{ID: 0, Content: "In a hole in the ground there lived a hobbit"},
+		{ID: 1, Content: "I have a dream that one day this nation will rise up"},
+	}
+	se := NewSearchEngine(docs, map[string]*nlp.Analyzer{FIELD_CONTENT: nlp.NewWhitespaceAnalyzer()})
+	corrector := se.Corrector
+
+	// the searches do not rebuild the corrector
+	se.DidYouMean("hobit")
+	if se.Corrector != corrector {
+		t.Fatalf("Expected the searches not to modify the search engine")
+	}
+
+	// the new occurrences of the known terms are counted
+	se.AddNewDocument(documents.Document{ID: 2, Content: "the hobbit met a dragon", Language: "en"})
+	if suggestions := se.SpellCorrector().Suggest("hobit", 1); len(suggestions) != 1 || suggestions[0].Frequency != 2 {
+		t.Errorf("Expected hobbit twice, got %v", suggestions)
+	}
+}
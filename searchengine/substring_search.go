@@ -0,0 +1,162 @@
+package searchengine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	documents "go4search/documents"
+	fmindex "go4search/searchengine/fmindex"
+	suffixarray "go4search/searchengine/suffixarray"
+	suffixtree "go4search/searchengine/suffixtree"
+)
+
+// SubstringIndex stores strings under integer indexes, and finds the indexes of the strings containing a substring.
+type SubstringIndex interface {
+	// Put adds the given index to the substring index under the given key.
+	Put(key string, index int)
+	// Search returns at most numElements indexes of the keys containing word. Use numElements <= 0 to get all.
+	Search(word string, numElements int) []int
+}
+
+// approximateSubstringIndex is a substring index that can also match substrings with errors.
+type approximateSubstringIndex interface {
+	SearchApproximate(word string, maxErrors int, numElements int) []suffixtree.ApproximateMatch
+}
+
+// ENV_SUBSTRING_INDEX selects the data structure behind the substring search mode, one of the SUBSTRING_INDEX_* values.
+// The generalized suffix tree is the default and the only one matching with typos; the suffix array and the FM-index
+// take less memory on large corpora.
+const ENV_SUBSTRING_INDEX = "GO4SEARCH_SUBSTRING_INDEX"
+
+const (
+	SUBSTRING_INDEX_SUFFIX_TREE  = "suffixtree"
+	SUBSTRING_INDEX_SUFFIX_ARRAY = "suffixarray"
+	SUBSTRING_INDEX_FM_INDEX     = "fmindex"
+)
+
+var ErrUnknownSubstringIndex = errors.New("unknown substring index")
+
+var _ SubstringIndex = suffixtree.NewGeneralizedSuffixTree()
+var _ SubstringIndex = suffixarray.New()
+var _ SubstringIndex = fmindex.New()
+var _ approximateSubstringIndex = suffixtree.NewGeneralizedSuffixTree()
+
+/**
+ * Create the empty substring index selected by the environment: GO4SEARCH_SUBSTRING_INDEX, one of the
+ * SUBSTRING_INDEX_* values in any case, the generalized suffix tree if unset.
+ *
+ * @return (SubstringIndex, error) the substring index, ErrUnknownSubstringIndex if the value is not supported
+ */
+func SubstringIndexFromEnv() (SubstringIndex, error) {
+	switch name := strings.ToLower(os.Getenv(ENV_SUBSTRING_INDEX)); name {
+	case "", SUBSTRING_INDEX_SUFFIX_TREE:
+		return suffixtree.NewGeneralizedSuffixTree(), nil
+	case SUBSTRING_INDEX_SUFFIX_ARRAY:
+		return suffixarray.New(), nil
+	case SUBSTRING_INDEX_FM_INDEX:
+		return fmindex.New(), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSubstringIndex, name)
+	}
+}
+
+/**
+ * Enable the substring search mode.
+ * All the documents of the search engine are added to the given substring index (e.g. a generalized suffix tree,
+ * or a suffix array or an FM-index for large corpora), and the documents added afterwards are added to it as well.
+ *
+ * @param substringIndex An empty substring index
+ */
+func (se *SearchEngine) EnableSubstringSearch(substringIndex SubstringIndex) {
+	for _, doc := range se.Documents {
+		substringIndex.Put(strings.ToLower(doc.Content), doc.ID)
+	}
+	// the static indexes (e.g. the FM-index) are built once, rather than by the first search
+	if builder, ok := substringIndex.(interface{ Build() }); ok {
+		builder.Build()
+	}
+	se.Substrings = substringIndex
+}
+
+/**
+ * Search for the documents containing the query as a substring.
+ * Unlike Search, the query is neither tokenized nor cleaned from stopwords, so arbitrary infixes (e.g. `obbi`)
+ * and text without reliable word boundaries (e.g. Chinese, Japanese or Korean) can be matched.
+ * The documents are scored with the number of occurrences of the query.
+ *
+ * @param query A substring to search for
+ * @param limit The maximum number of results to return
+ *
+ * @return []documents.Document
+ */
+func (se *SearchEngine) SubstringSearch(query string, limit int) []documents.Document {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if se.Substrings == nil || query == "" {
+		return []documents.Document{}
+	}
+
+	results := make([]documents.Document, 0)
+	for _, docID := range se.Substrings.Search(query, -1) {
+		results = append(
+			results,
+			documents.Document{
+				ID:      docID,
+				Content: se.Documents[docID].Content,
+				Score:   float64(strings.Count(strings.ToLower(se.Documents[docID].Content), query)),
+			},
+		)
+	}
+
+	// sort the results by score in descending order, then by document ID
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	// return the at most top N results
+	if len(results) > limit {
+		return results[:limit]
+	}
+	return results
+}
+
+/**
+ * Search for the documents containing the query as a substring, allowing up to maxErrors typos
+ * (rune insertions, deletions or substitutions).
+ * The documents are scored with 1 / (1 + the number of errors), so the exact matches come first.
+ * Only the substring indexes supporting approximate matching (e.g. the generalized suffix tree) can be used.
+ *
+ * @param query A substring to search for
+ * @param maxErrors The maximum edit distance between the query and the matched substring
+ * @param limit The maximum number of results to return
+ *
+ * @return ([]documents.Document, error) the results, errors.ErrUnsupported if the substring index cannot match with errors
+ */
+func (se *SearchEngine) ApproximateSubstringSearch(query string, maxErrors int, limit int) ([]documents.Document, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if se.Substrings == nil || query == "" {
+		return []documents.Document{}, nil
+	}
+	approximateIndex, ok := se.Substrings.(approximateSubstringIndex)
+	if !ok {
+		return nil, fmt.Errorf("%w: the substring index does not support approximate matching", errors.ErrUnsupported)
+	}
+
+	results := make([]documents.Document, 0)
+	for _, match := range approximateIndex.SearchApproximate(query, maxErrors, limit) {
+		results = append(
+			results,
+			documents.Document{
+				ID:      match.Index,
+				Content: se.Documents[match.Index].Content,
+				Score:   1.0 / float64(1+match.Distance),
+			},
+		)
+	}
+	return results, nil
+}
@@ -0,0 +1,131 @@
+package searchengine
+
+import (
+	"errors"
+	"testing"
+
+	documents "go4search/documents"
+	nlp "go4search/nlp"
+	fmindex "go4search/searchengine/fmindex"
+	suffixarray "go4search/searchengine/suffixarray"
+	suffixtree "go4search/searchengine/suffixtree"
+)
+
+func TestSubstringSearch(t *testing.T) {
+	docs := []documents.Document{
+		{ID: 0, Content: "In a hole in the ground there lived a hobbit. It was a hobbit-hole"},
+		{ID: 1, Content: "Hobbits love happiness"},
+		{ID: 2, Content: "아이유(IU, 본명: 이지은)는 대한민국의 가수이자 배우이다."},
+	}
+	index, sbf := BuildInvertedIndex(docs, false)
+	se := SearchEngine{Index: index, Documents: docs, Bloomfilter: sbf}
+	se.EnableSubstringSearch(suffixtree.NewGeneralizedSuffixTree())
+
+	results := se.SubstringSearch("OBBI", 10)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %v", results)
+	}
+	// document 0 contains "obbi" twice
+	if results[0].ID != 0 || results[0].Score != 2 || results[1].ID != 1 || results[1].Score != 1 {
+		t.Errorf("Unexpected results %v", results)
+	}
+
+	results = se.SubstringSearch("대한민국", 10)
+	if len(results) != 1 || results[0].ID != 2 {
+		t.Errorf("Expected document 2, got %v", results)
+	}
+
+	if results := se.SubstringSearch("dragon", 10); len(results) != 0 {
+		t.Errorf("Expected no result, got %v", results)
+	}
+}
+
+func TestSubstringSearchAddedDocument(t *testing.T) {
+	docs := []documents.Document{
+		{ID: 0, Content: "In a hole in the ground there lived a hobbit"},
+		{ID: 1, Content: "Hobbits love happiness"},
+	}
+	index, sbf := BuildInvertedIndex(docs, false)
+	analyzers := map[string]*nlp.Analyzer{FIELD_CONTENT: nlp.NewWhitespaceAnalyzer()}
+	se := SearchEngine{Index: index, Documents: docs, Bloomfilter: sbf, Analyzers: analyzers}
+	se.EnableSubstringSearch(suffixtree.NewGeneralizedSuffixTree())
+
+	// the documents added later are put under their ID
+	se.AddNewDocument(documents.Document{ID: 2, Content: "The hobbit met a dragon", Language: "en"})
+	if results := se.SubstringSearch("drago", 10); len(results) != 1 || results[0].ID != 2 {
+		t.Errorf("Expected document 2, got %v", results)
+	}
+}
+
+func TestSubstringSearchWithFMIndex(t *testing.T) {
+	docs := []documents.Document{
+		{ID: 0, Content: "In a hole in the ground there lived a hobbit. It was a hobbit-hole"},
+		{ID: 1, Content: "Hobbits love happiness"},
+		{ID: 2, Content: "아이유(IU, 본명: 이지은)는 대한민국의 가수이자 배우이다."},
+	}
+	index, sbf := BuildInvertedIndex(docs, false)
+	se := SearchEngine{Index: index, Documents: docs, Bloomfilter: sbf}
+	se.EnableSubstringSearch(fmindex.New())
+
+	results := se.SubstringSearch("obbi", 10)
+	if len(results) != 2 || results[0].ID != 0 || results[1].ID != 1 {
+		t.Errorf("Expected documents 0 and 1, got %v", results)
+	}
+	if results := se.SubstringSearch("이지은", 10); len(results) != 1 || results[0].ID != 2 {
+		t.Errorf("Expected document 2, got %v", results)
+	}
+}
+
+func TestSubstringIndexFromEnv(t *testing.T) {
+	t.Setenv(ENV_SUBSTRING_INDEX, "")
+	if index, err := SubstringIndexFromEnv(); err != nil {
+		t.Fatal(err)
+	} else if _, ok := index.(approximateSubstringIndex); !ok {
+		t.Errorf("Expected the generalized suffix tree by default, got %T", index)
+	}
+	t.Setenv(ENV_SUBSTRING_INDEX, "SuffixArray")
+	if index, err := SubstringIndexFromEnv(); err != nil {
+		t.Fatal(err)
+	} else if _, ok := index.(*suffixarray.SuffixArray); !ok {
+		t.Errorf("Expected the suffix array, got %T", index)
+	}
+	t.Setenv(ENV_SUBSTRING_INDEX, SUBSTRING_INDEX_FM_INDEX)
+	if index, err := SubstringIndexFromEnv(); err != nil {
+		t.Fatal(err)
+	} else if _, ok := index.(*fmindex.FMIndex); !ok {
+		t.Errorf("Expected the FM-index, got %T", index)
+	}
+	t.Setenv(ENV_SUBSTRING_INDEX, "btree")
+	if _, err := SubstringIndexFromEnv(); !errors.Is(err, ErrUnknownSubstringIndex) {
+		t.Errorf("Expected ErrUnknownSubstringIndex, got %v", err)
+	}
+}
+
+func TestApproximateSubstringSearch(t *testing.T) {
+	docs := []documents.Document{
+		{ID: 0, Content: "In a hole in the ground there lived a hobbit"},
+		{ID: 1, Content: "Hobbits love happiness"},
+		{ID: 2, Content: "I have a dream"},
+	}
+	index, sbf := BuildInvertedIndex(docs, false)
+	se := SearchEngine{Index: index, Documents: docs, Bloomfilter: sbf}
+	se.EnableSubstringSearch(suffixtree.NewGeneralizedSuffixTree())
+
+	results, err := se.ApproximateSubstringSearch("Hobit", 1, 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[0].Score != 0.5 {
+		t.Errorf("Expected documents 0 and 1 with one error, got %v", results)
+	}
+
+	results, _ = se.ApproximateSubstringSearch("dream", 1, 10)
+	if len(results) != 1 || results[0].ID != 2 || results[0].Score != 1 {
+		t.Errorf("Expected an exact match of document 2, got %v", results)
+	}
+
+	se.EnableSubstringSearch(suffixarray.New())
+	if _, err := se.ApproximateSubstringSearch("hobit", 1, 10); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Expected errors.ErrUnsupported with a suffix array, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package suffixarray
+
+/*
+ * SAIS builds the suffix array of text with the SA-IS algorithm (Nong, Zhang and Chan, 2009) in linear time.
+ *
+ * The values of text must be in [0, alphabetSize), and the last value must be a 0 sentinel that occurs nowhere else.
+ *
+ * @param text the text to sort the suffixes of
+ * @param alphabetSize the number of distinct values text may contain
+ * @return the starting positions of the suffixes of text in lexicographic order
+ */
+func SAIS(text []int32, alphabetSize int) []int32 {
+	n := len(text)
+	sa := make([]int32, n)
+	if n == 1 {
+		return sa
+	}
+
+	// classify the suffixes: a suffix is S-type if it is smaller than the next one, L-type otherwise
+	sType := make([]bool, n)
+	sType[n-1] = true
+	for i := n - 2; i >= 0; i-- {
+		sType[i] = text[i] < text[i+1] || (text[i] == text[i+1] && sType[i+1])
+	}
+	isLMS := func(i int) bool {
+		return i > 0 && sType[i] && !sType[i-1]
+	}
+
+	bucketSizes := make([]int32, alphabetSize)
+	for _, c := range text {
+		bucketSizes[c]++
+	}
+	// bucketHeads returns the first slot of each bucket, bucketTails one past the last slot of each bucket
+	bucketHeads := func() []int32 {
+		heads := make([]int32, alphabetSize)
+		var sum int32
+		for c, size := range bucketSizes {
+			heads[c] = sum
+			sum += size
+		}
+		return heads
+	}
+	bucketTails := func() []int32 {
+		tails := make([]int32, alphabetSize)
+		var sum int32
+		for c, size := range bucketSizes {
+			sum += size
+			tails[c] = sum
+		}
+		return tails
+	}
+
+	// induce sorts all the suffixes from the given LMS suffixes, placed in order at the end of their buckets
+	induce := func(lms []int32) {
+		for i := range sa {
+			sa[i] = -1
+		}
+		tails := bucketTails()
+		for i := len(lms) - 1; i >= 0; i-- {
+			c := text[lms[i]]
+			tails[c]--
+			sa[tails[c]] = lms[i]
+		}
+		heads := bucketHeads()
+		for i := 0; i < n; i++ {
+			if j := sa[i] - 1; sa[i] > 0 && !sType[j] {
+				c := text[j]
+				sa[heads[c]] = j
+				heads[c]++
+			}
+		}
+		tails = bucketTails()
+		for i := n - 1; i >= 0; i-- {
+			if j := sa[i] - 1; sa[i] > 0 && sType[j] {
+				c := text[j]
+				tails[c]--
+				sa[tails[c]] = j
+			}
+		}
+	}
+
+	// step 1: sort the LMS substrings by inducing from the LMS suffixes in text order
+	lms := make([]int32, 0)
+	for i := 1; i < n; i++ {
+		if isLMS(i) {
+			lms = append(lms, int32(i))
+		}
+	}
+	induce(lms)
+
+	// step 2: name the LMS substrings, equal substrings get the same name
+	lmsEqual := func(a, b int) bool {
+		for i := 0; ; i++ {
+			if text[a+i] != text[b+i] || sType[a+i] != sType[b+i] {
+				return false
+			}
+			if i > 0 && (isLMS(a+i) || isLMS(b+i)) {
+				return isLMS(a+i) && isLMS(b+i)
+			}
+		}
+	}
+	names := make([]int32, n)
+	for i := range names {
+		names[i] = -1
+	}
+	name := int32(-1)
+	prev := -1
+	for _, p := range sa {
+		if !isLMS(int(p)) {
+			continue
+		}
+		if prev < 0 || !lmsEqual(prev, int(p)) {
+			name++
+		}
+		names[p] = name
+		prev = int(p)
+	}
+
+	// step 3: sort the LMS suffixes, recursively if some LMS substrings are equal
+	reduced := make([]int32, 0, len(lms))
+	for _, p := range lms {
+		reduced = append(reduced, names[p])
+	}
+	var reducedSA []int32
+	if int(name)+1 == len(lms) {
+		reducedSA = make([]int32, len(lms))
+		for i, c := range reduced {
+			reducedSA[c] = int32(i)
+		}
+	} else {
+		reducedSA = SAIS(reduced, int(name)+1)
+	}
+
+	// step 4: induce the final order from the sorted LMS suffixes
+	sortedLMS := make([]int32, len(lms))
+	for i, r := range reducedSA {
+		sortedLMS[i] = lms[r]
+	}
+	induce(sortedLMS)
+
+	return sa
+}
+
+/*
+ * kasai builds the LCP array of text with Kasai's algorithm in linear time.
+ * lcp[i] is the length of the longest common prefix of the suffixes sa[i-1] and sa[i], and lcp[0] is 0.
+ */
+func kasai(text []int32, sa []int32) []int32 {
+	n := len(text)
+	rank := make([]int32, n)
+	for i, p := range sa {
+		rank[p] = int32(i)
+	}
+
+	lcp := make([]int32, n)
+	h := 0
+	for i := 0; i < n; i++ {
+		if rank[i] == 0 {
+			h = 0
+			continue
+		}
+		j := int(sa[rank[i]-1])
+		for i+h < n && j+h < n && text[i+h] == text[j+h] {
+			h++
+		}
+		lcp[rank[i]] = int32(h)
+		if h > 0 {
+			h--
+		}
+	}
+	return lcp
+}
@@ -0,0 +1,141 @@
+package suffixarray
+
+import (
+	"sort"
+)
+
+const (
+	separator = -1 // terminates each key in the text, so that no match spans two keys
+)
+
+// SuffixArray is a generalized suffix array with its LCP array, over a set of keys associated with indexes.
+// It answers the same queries as the generalized suffix tree, but only stores a few flat int32 arrays
+// (the text, the suffix array and the LCP array) instead of a node and an edge per suffix branch.
+//
+// Keys are added with Put, and the arrays are (re)built with SA-IS on the next Search. Adding keys after a search
+// triggers a full rebuild, so keys should be added in bulk before searching.
+type SuffixArray struct {
+	text    []int32 // the runes of the keys, each key followed by a separator
+	starts  []int32 // starts[k] is the position of the k-th key in text
+	indexes []int   // indexes[k] is the index associated with the k-th key
+	sa      []int32 // the suffix array of text (the empty suffix at len(text) comes first)
+	lcp     []int32 // lcp[i] is the longest common prefix length of the suffixes sa[i-1] and sa[i]
+	built   bool
+}
+
+func New() *SuffixArray {
+	return &SuffixArray{}
+}
+
+// Put adds the specified index to the suffix array under the given key.
+func (s *SuffixArray) Put(key string, index int) {
+	s.starts = append(s.starts, int32(len(s.text)))
+	s.indexes = append(s.indexes, index)
+	for _, r := range key {
+		s.text = append(s.text, r)
+	}
+	s.text = append(s.text, separator)
+	s.built = false
+}
+
+// Build builds the suffix and LCP arrays of the keys added so far.
+func (s *SuffixArray) Build() {
+	// SA-IS needs a dense alphabet, so the runes are replaced by their rank among the distinct runes of the text,
+	// after the 0 sentinel and the separator
+	alphabet := make([]int32, 0)
+	seen := make(map[int32]bool)
+	for _, r := range s.text {
+		if r != separator && !seen[r] {
+			seen[r] = true
+			alphabet = append(alphabet, r)
+		}
+	}
+	sort.Slice(alphabet, func(i, j int) bool { return alphabet[i] < alphabet[j] })
+	ranks := make(map[int32]int32, len(alphabet))
+	for i, r := range alphabet {
+		ranks[r] = int32(i + 2)
+	}
+
+	encoded := make([]int32, len(s.text)+1)
+	for i, r := range s.text {
+		if r == separator {
+			encoded[i] = 1
+		} else {
+			encoded[i] = ranks[r]
+		}
+	}
+
+	s.sa = SAIS(encoded, len(alphabet)+2)
+	s.lcp = kasai(encoded, s.sa)
+	s.built = true
+}
+
+// Search searches for the given word within the suffix array and returns at most the given number of matches.
+// numElements <= 0 get all matches
+func (s *SuffixArray) Search(word string, numElements int) []int {
+	lo, hi := s.searchRange(word)
+	if lo >= hi {
+		return nil
+	}
+
+	ret := make([]int, 0)
+	seen := make(map[int]bool)
+	for i := lo; i < hi; i++ {
+		index := s.indexes[s.keyOf(s.sa[i])]
+		if seen[index] {
+			continue
+		}
+		seen[index] = true
+		ret = append(ret, index)
+		if numElements > 0 && len(ret) == numElements {
+			break
+		}
+	}
+	return ret
+}
+
+// searchRange returns the range [lo, hi) of the suffix array holding the suffixes that start with word.
+func (s *SuffixArray) searchRange(word string) (int, int) {
+	if !s.built {
+		s.Build()
+	}
+
+	pattern := []rune(word)
+	if len(pattern) == 0 {
+		return 0, 0
+	}
+
+	// binary search for the first suffix not smaller than the pattern
+	lo := sort.Search(len(s.sa), func(i int) bool {
+		return s.compare(s.sa[i], pattern) >= 0
+	})
+	if lo == len(s.sa) || s.compare(s.sa[lo], pattern) != 0 {
+		return 0, 0
+	}
+
+	// the following suffixes start with the pattern as long as they share at least len(pattern) values
+	hi := lo + 1
+	for hi < len(s.sa) && int(s.lcp[hi]) >= len(pattern) {
+		hi++
+	}
+	return lo, hi
+}
+
+// compare compares the prefix of the suffix starting at pos with the pattern.
+func (s *SuffixArray) compare(pos int32, pattern []rune) int {
+	for i, r := range pattern {
+		p := int(pos) + i
+		if p >= len(s.text) || s.text[p] < r {
+			return -1
+		}
+		if s.text[p] > r {
+			return 1
+		}
+	}
+	return 0
+}
+
+// keyOf returns the number of the key containing the text position pos.
+func (s *SuffixArray) keyOf(pos int32) int {
+	return sort.Search(len(s.starts), func(k int) bool { return s.starts[k] > pos }) - 1
+}
@@ -0,0 +1,208 @@
+package suffixarray
+
+import (
+	"math/rand"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	suffixtree "go4search/searchengine/suffixtree"
+)
+
+func TestSuffixArray(t *testing.T) {
+	words := []string{"banana", "apple", "中文app"}
+	sa := New()
+	for k, word := range words {
+		sa.Put(word, k)
+	}
+
+	indexes := sa.Search("a", -1)
+	if len(indexes) != 3 {
+		t.Error("indexes len should be 3,but ", len(indexes))
+	}
+
+	indexes = sa.Search("文", 0)
+	if len(indexes) != 1 || indexes[0] != 2 {
+		t.Error("indexes should be [2], but ", indexes)
+	}
+
+	if indexes := sa.Search("ana", 1); len(indexes) != 1 {
+		t.Error("indexes len should be limited to 1, but ", len(indexes))
+	}
+
+	// a match must not span two keys
+	if indexes := sa.Search("aa", -1); len(indexes) != 0 {
+		t.Error("indexes should be empty, but ", indexes)
+	}
+}
+
+func TestSuffixArrayAgainstBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for iter := 0; iter < 200; iter++ {
+		words := randomWords(r, 1+r.Intn(8), 10, "abc")
+		sa := New()
+		for k, word := range words {
+			sa.Put(word, k)
+		}
+
+		for _, word := range words {
+			for i := 0; i < len(word); i++ {
+				for j := i + 1; j <= len(word); j++ {
+					sub := word[i:j]
+					got := sa.Search(sub, -1)
+					sort.Ints(got)
+
+					expected := make([]int, 0)
+					for k, w := range words {
+						if strings.Contains(w, sub) {
+							expected = append(expected, k)
+						}
+					}
+					if !equalInts(got, expected) {
+						t.Fatalf("%v: search %q, expected %v, got %v", words, sub, expected, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSAIS(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for iter := 0; iter < 500; iter++ {
+		n := 1 + r.Intn(30)
+		text := make([]int32, n+1)
+		for i := 0; i < n; i++ {
+			text[i] = int32(1 + r.Intn(3))
+		}
+
+		sa := SAIS(text, 4)
+		for i := 1; i < len(sa); i++ {
+			if compareSuffixes(text, sa[i-1], sa[i]) >= 0 {
+				t.Fatalf("%v: suffixes %d and %d are not sorted in %v", text, sa[i-1], sa[i], sa)
+			}
+		}
+	}
+}
+
+func compareSuffixes(text []int32, a, b int32) int {
+	for a < int32(len(text)) && b < int32(len(text)) {
+		if text[a] != text[b] {
+			return int(text[a] - text[b])
+		}
+		a++
+		b++
+	}
+	return int(b - a)
+}
+
+func randomWords(r *rand.Rand, count int, maxLength int, alphabet string) []string {
+	words := make([]string, count)
+	for i := range words {
+		b := make([]byte, 1+r.Intn(maxLength))
+		for j := range b {
+			b[j] = alphabet[r.Intn(len(alphabet))]
+		}
+		words[i] = string(b)
+	}
+	return words
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// benchmarkCorpus returns documents made of random words, as a stand-in for crawled pages.
+func benchmarkCorpus() []string {
+	r := rand.New(rand.NewSource(42))
+	vocabulary := randomWords(r, 2000, 10, "abcdefghijklmnopqrstuvwxyz")
+	docs := make([]string, 300)
+	for i := range docs {
+		words := make([]string, 50)
+		for j := range words {
+			words[j] = vocabulary[r.Intn(len(vocabulary))]
+		}
+		docs[i] = strings.Join(words, " ")
+	}
+	return docs
+}
+
+var benchmarkQueries = []string{"ab", "the", "xyz", "lmn", "qu", "e a", "zzz"}
+
+// heapGrowth returns the number of heap bytes still allocated by build once it has returned.
+func heapGrowth(build func() interface{}) (uint64, interface{}) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	index := build()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	return after.HeapAlloc - before.HeapAlloc, index
+}
+
+func BenchmarkSuffixArrayBuild(b *testing.B) {
+	docs := benchmarkCorpus()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		bytes, index := heapGrowth(func() interface{} {
+			sa := New()
+			for k, doc := range docs {
+				sa.Put(doc, k)
+			}
+			sa.Build()
+			return sa
+		})
+		runtime.KeepAlive(index)
+		b.ReportMetric(float64(bytes), "heap-bytes")
+	}
+}
+
+func BenchmarkSuffixTreeBuild(b *testing.B) {
+	docs := benchmarkCorpus()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		bytes, index := heapGrowth(func() interface{} {
+			tree := suffixtree.NewGeneralizedSuffixTree()
+			for k, doc := range docs {
+				tree.Put(doc, k)
+			}
+			return tree
+		})
+		runtime.KeepAlive(index)
+		b.ReportMetric(float64(bytes), "heap-bytes")
+	}
+}
+
+func BenchmarkSuffixArraySearch(b *testing.B) {
+	sa := New()
+	for k, doc := range benchmarkCorpus() {
+		sa.Put(doc, k)
+	}
+	sa.Build()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sa.Search(benchmarkQueries[i%len(benchmarkQueries)], -1)
+	}
+}
+
+func BenchmarkSuffixTreeSearch(b *testing.B) {
+	tree := suffixtree.NewGeneralizedSuffixTree()
+	for k, doc := range benchmarkCorpus() {
+		tree.Put(doc, k)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Search(benchmarkQueries[i%len(benchmarkQueries)], -1)
+	}
+}
@@ -0,0 +1,137 @@
+package suffixtree
+
+import (
+	"sort"
+	"strings"
+)
+
+// Phrase is a substring repeated across the keys of the GST.
+type Phrase struct {
+	Text  string // the repeated substring
+	Count int    // the number of indexes whose keys contain the substring
+}
+
+// LongestCommonSubstring returns the longest substring contained in the keys of every given index.
+// It returns the empty string if the keys share no substring, or if an index is not in the GST.
+func (t *generalizedSuffixTree) LongestCommonSubstring(indexes []int) string {
+	wanted := make(map[int]bool)
+	for _, index := range indexes {
+		if _, ok := t.keyCounts[index]; !ok {
+			return ""
+		}
+		wanted[index] = true
+	}
+	if len(wanted) == 0 {
+		return ""
+	}
+
+	// the path to a node is contained in exactly the keys found in the subtree of the node, so the
+	// answer is the deepest node whose subtree holds all the wanted indexes
+	var best []rune
+	t.walkIndexes(t.root, nil, func(path []rune, indexes []int, maxChildCount int) {
+		count := 0
+		for _, index := range indexes {
+			if wanted[index] {
+				count++
+			}
+		}
+		if count == len(wanted) && len(path) > len(best) {
+			best = append([]rune(nil), path...)
+		}
+	})
+	return string(best)
+}
+
+// RepeatedPhrases returns the substrings of at least minLength runes contained in the keys of at least minCount
+// indexes, the ones shared by the most indexes first, then the longest ones.
+// Only maximal substrings are returned: a substring is dropped if a longer one containing it is shared by as many
+// indexes. Use limit <= 0 to get all of them.
+//
+// Over a corpus of crawled pages, the phrases shared by many pages are typically boilerplate (navigation, footers,
+// cookie banners) that should be stripped before indexing.
+func (t *generalizedSuffixTree) RepeatedPhrases(minLength int, minCount int, limit int) []Phrase {
+	if minCount < 2 {
+		minCount = 2
+	}
+
+	candidates := make([]Phrase, 0)
+	t.walkIndexes(t.root, nil, func(path []rune, indexes []int, maxChildCount int) {
+		if len(path) < minLength || len(indexes) < minCount {
+			return
+		}
+		// extending the path into a child with the same count gives a longer phrase, so only keep the longest
+		if maxChildCount == len(indexes) {
+			return
+		}
+		candidates = append(candidates, Phrase{Text: string(path), Count: len(indexes)})
+	})
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Count != candidates[j].Count {
+			return candidates[i].Count > candidates[j].Count
+		}
+		if len(candidates[i].Text) != len(candidates[j].Text) {
+			return len(candidates[i].Text) > len(candidates[j].Text)
+		}
+		return candidates[i].Text < candidates[j].Text
+	})
+
+	// drop the phrases that are contained in a longer phrase shared by at least as many indexes
+	phrases := make([]Phrase, 0)
+	for _, candidate := range candidates {
+		contained := false
+		for _, phrase := range phrases {
+			if strings.Contains(phrase.Text, candidate.Text) {
+				contained = true
+				break
+			}
+		}
+		if contained {
+			continue
+		}
+		phrases = append(phrases, candidate)
+		if limit > 0 && len(phrases) == limit {
+			break
+		}
+	}
+	return phrases
+}
+
+// walkIndexes visits the subtree of n in post-order, and calls fn with the path leading to every node,
+// the sorted indexes found in the subtree of the node, and the largest number of indexes found in the
+// subtree of one of its children. It returns the indexes found in the subtree of n.
+func (t *generalizedSuffixTree) walkIndexes(n *node, path []rune, fn func(path []rune, indexes []int, maxChildCount int)) []int {
+	indexes := append([]int(nil), n.data...)
+	maxChildCount := 0
+	for _, e := range n.edges {
+		childPath := make([]rune, 0, len(path)+len(e.label))
+		childPath = append(append(childPath, path...), e.label...)
+		childIndexes := t.walkIndexes(e.node, childPath, fn)
+		maxChildCount = max(maxChildCount, len(childIndexes))
+		indexes = mergeSorted(indexes, childIndexes)
+	}
+	fn(path, indexes, maxChildCount)
+	return indexes
+}
+
+// mergeSorted returns the sorted union of two sorted slices of distinct ints.
+func mergeSorted(a, b []int) []int {
+	merged := make([]int, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			merged = append(merged, a[i])
+			i++
+		case a[i] > b[j]:
+			merged = append(merged, b[j])
+			j++
+		default:
+			merged = append(merged, a[i])
+			i++
+			j++
+		}
+	}
+	merged = append(merged, a[i:]...)
+	return append(merged, b[j:]...)
+}
@@ -0,0 +1,95 @@
+package suffixtree
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestLongestCommonSubstring(t *testing.T) {
+	tree := NewGeneralizedSuffixTree()
+	tree.Put("the quick brown fox jumped", 0)
+	tree.Put("a quick brown dog slept", 1)
+	tree.Put("quick brownies", 2)
+
+	if lcs := tree.LongestCommonSubstring([]int{0, 1}); lcs != " quick brown " {
+		t.Errorf("expected \" quick brown \", got %q", lcs)
+	}
+	if lcs := tree.LongestCommonSubstring([]int{0, 1, 2}); lcs != "quick brown" {
+		t.Errorf("expected \"quick brown\", got %q", lcs)
+	}
+	if lcs := tree.LongestCommonSubstring([]int{0, 42}); lcs != "" {
+		t.Errorf("expected no common substring with an unknown index, got %q", lcs)
+	}
+}
+
+func TestLongestCommonSubstringAgainstBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for iter := 0; iter < 300; iter++ {
+		keys := make(map[int]string)
+		tree := NewGeneralizedSuffixTree()
+		for index := 0; index < 2+r.Intn(3); index++ {
+			keys[index] = randomKey(r, 10, "abc")
+			tree.Put(keys[index], index)
+		}
+
+		indexes := make([]int, 0)
+		for index := range keys {
+			indexes = append(indexes, index)
+		}
+
+		// the longest substring of the first key contained in all the others
+		expected := ""
+		first := keys[0]
+		for i := 0; i < len(first); i++ {
+			for j := i + 1; j <= len(first); j++ {
+				common := true
+				for _, key := range keys {
+					common = common && strings.Contains(key, first[i:j])
+				}
+				if common && j-i > len(expected) {
+					expected = first[i:j]
+				}
+			}
+		}
+
+		if lcs := tree.LongestCommonSubstring(indexes); len(lcs) != len(expected) {
+			t.Fatalf("%v: expected a common substring like %q, got %q", keys, expected, lcs)
+		}
+	}
+}
+
+func TestRepeatedPhrases(t *testing.T) {
+	footer := " | Home | About us | Contact | Copyright 2024"
+	tree := NewGeneralizedSuffixTree()
+	tree.Put("In a hole in the ground there lived a hobbit"+footer, 0)
+	tree.Put("Call me Ishmael"+footer, 1)
+	tree.Put("It was the best of times"+footer, 2)
+	tree.Put("It was a bright cold day in April", 3)
+
+	phrases := tree.RepeatedPhrases(10, 3, 0)
+	if len(phrases) != 1 || phrases[0].Text != footer || phrases[0].Count != 3 {
+		t.Errorf("expected the footer shared by 3 pages, got %v", phrases)
+	}
+
+	phrases = tree.RepeatedPhrases(7, 2, 0)
+	if len(phrases) < 2 || phrases[0].Text != footer {
+		t.Fatalf("expected the footer first, got %v", phrases)
+	}
+	found := false
+	for _, phrase := range phrases {
+		if phrase.Text == "It was " && phrase.Count == 2 {
+			found = true
+		}
+		if phrase.Text != footer && strings.Contains(footer, phrase.Text) && phrase.Count <= 3 {
+			t.Errorf("%q is contained in the footer shared by as many pages", phrase.Text)
+		}
+	}
+	if !found {
+		t.Errorf("expected \"It was \" shared by 2 pages, got %v", phrases)
+	}
+
+	if phrases := tree.RepeatedPhrases(7, 2, 1); len(phrases) != 1 {
+		t.Errorf("expected 1 phrase, got %v", phrases)
+	}
+}
@@ -0,0 +1,118 @@
+package suffixtree
+
+import (
+	"sort"
+)
+
+// ApproximateMatch is an index whose keys contain a substring within some edit distance of the searched word.
+type ApproximateMatch struct {
+	Index    int
+	Distance int // the smallest edit distance between the word and a substring of the keys
+}
+
+// SearchApproximate searches for the given word within the GST allowing up to maxErrors rune insertions, deletions
+// or substitutions, and returns at most the given number of matches, the closest ones first.
+// numElments <= 0 get all matches
+//
+// Every substring of the keys is a prefix of a path from the root, so the tree is walked depth-first while the
+// edit distances between the prefixes of the word and the path are kept in a dynamic programming column.
+// A branch is abandoned as soon as every prefix of the word is more than maxErrors edits away from the path.
+func (t *generalizedSuffixTree) SearchApproximate(word string, maxErrors int, numElements int) []ApproximateMatch {
+	pattern := []rune(word)
+
+	column := make([]int, len(pattern)+1)
+	for i := range column {
+		column[i] = i
+	}
+
+	// the nodes whose subtree matches, with the smallest distance at which they match
+	matched := make(map[*node]int)
+	if column[len(pattern)] <= maxErrors {
+		// the word is short enough to match the empty substring of every key
+		matched[t.root] = column[len(pattern)]
+	}
+	t.searchApproximate(t.root, pattern, column, maxErrors, matched)
+
+	nodes := make([]*node, 0, len(matched))
+	for n := range matched {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return matched[nodes[i]] < matched[nodes[j]] })
+
+	// the closest nodes come first, so the first distance found for an index is its smallest one
+	distances := make(map[int]int)
+	for _, n := range nodes {
+		for _, index := range n.getData(-1) {
+			if _, ok := distances[index]; !ok {
+				distances[index] = matched[n]
+			}
+		}
+	}
+
+	matches := make([]ApproximateMatch, 0, len(distances))
+	for index, distance := range distances {
+		matches = append(matches, ApproximateMatch{Index: index, Distance: distance})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].Index < matches[j].Index
+	})
+	if numElements > 0 && len(matches) > numElements {
+		matches = matches[:numElements]
+	}
+	return matches
+}
+
+// searchApproximate extends the column of edit distances along every edge of n, and records in matched the
+// nodes reached by a path matching the pattern within maxErrors edits.
+func (t *generalizedSuffixTree) searchApproximate(n *node, pattern []rune, column []int, maxErrors int, matched map[*node]int) {
+	for _, e := range n.edges {
+		current := column
+		best := -1
+		alive := true
+		for _, r := range e.label {
+			current = nextColumn(current, pattern, r)
+			if distance := current[len(pattern)]; distance <= maxErrors && (best < 0 || distance < best) {
+				best = distance
+			}
+			if minOf(current) > maxErrors {
+				alive = false
+				break
+			}
+		}
+
+		// a match anywhere along the edge is contained in the keys of the subtree the edge leads to
+		if best >= 0 {
+			if distance, ok := matched[e.node]; !ok || best < distance {
+				matched[e.node] = best
+			}
+		}
+		if alive {
+			t.searchApproximate(e.node, pattern, current, maxErrors, matched)
+		}
+	}
+}
+
+// nextColumn returns the edit distances between the prefixes of the pattern and the path extended with r.
+func nextColumn(column []int, pattern []rune, r rune) []int {
+	next := make([]int, len(column))
+	next[0] = column[0] + 1
+	for i := 1; i < len(column); i++ {
+		cost := 1
+		if pattern[i-1] == r {
+			cost = 0
+		}
+		next[i] = min(column[i]+1, next[i-1]+1, column[i-1]+cost)
+	}
+	return next
+}
+
+func minOf(values []int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		m = min(m, v)
+	}
+	return m
+}
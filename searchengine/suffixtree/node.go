@@ -20,6 +20,18 @@ type node struct {
 	 * is the node denoted by the path that corresponds to str without the first rune.
 	 */
 	suffix *node
+	/**
+	 * The starts of the suffixes of the keys whose path leads to this node, see generalizedSuffixTree.addStarts.
+	 */
+	starts []suffixStart
+}
+
+// suffixStart is the start of a suffix of a key, where the words the suffix starts with occur.
+type suffixStart struct {
+	index  int // the index the key has been added under
+	key    int // the number of the key among the keys of the index
+	offset int // the byte offset of the suffix in the key
+	length int // the byte length of the suffix
 }
 
 /*
@@ -32,17 +44,18 @@ type node struct {
  * @return the first numElements associated to this node and children
  */
 func (n *node) getData(numElements int) (ret []int) {
-
-	if numElements > 0 {
+	// the payload is copied, appending the data of the children must not write into its backing array
+	limited := numElements > 0
+	if limited {
 		if numElements > len(n.data) {
 			numElements -= len(n.data)
-			ret = n.data
+			ret = append([]int(nil), n.data...)
 		} else {
-			ret = n.data[:numElements]
+			ret = append([]int(nil), n.data[:numElements]...)
 			return
 		}
 	} else {
-		ret = n.data
+		ret = append([]int(nil), n.data...)
 	}
 
 	// need to get more matches from child nodes. This is what may waste time
@@ -56,13 +69,13 @@ func (n *node) getData(numElements int) (ret []int) {
 				}
 			}
 
-			if numElements > 0 {
+			if limited {
 				numElements--
 			}
 			ret = append(ret, idx)
 		}
 
-		if numElements == 0 {
+		if limited && numElements == 0 {
 			break
 		}
 	}
@@ -119,8 +132,44 @@ func (n *node) search(r rune) int {
 	return -1
 }
 
+// addIndex inserts idx in the payload, which is kept sorted for contains
 func (n *node) addIndex(idx int) {
-	n.data = append(n.data, idx)
+	i := sort.SearchInts(n.data, idx)
+	n.data = append(n.data, 0)
+	copy(n.data[i+1:], n.data[i:])
+	n.data[i] = idx
+}
+
+// removeIndex removes idx from the payload, if present
+func (n *node) removeIndex(idx int) {
+	i := sort.SearchInts(n.data, idx)
+	if i < len(n.data) && n.data[i] == idx {
+		n.data = append(n.data[:i], n.data[i+1:]...)
+	}
+}
+
+// hasStart returns whether the start of a suffix of the index with the given offset and length is recorded on this node.
+func (n *node) hasStart(index int, offset int, length int) bool {
+	if n == nil {
+		return false
+	}
+	for _, start := range n.starts {
+		if start.index == index && start.offset == offset && start.length == length {
+			return true
+		}
+	}
+	return false
+}
+
+// removeStarts removes the suffix starts of the keys of idx
+func (n *node) removeStarts(idx int) {
+	starts := n.starts[:0]
+	for _, start := range n.starts {
+		if start.index != idx {
+			starts = append(starts, start)
+		}
+	}
+	n.starts = starts
 }
 
 func newNode() *node {
@@ -0,0 +1,328 @@
+package suffixtree
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+var serializationMagic = []byte("GST\x02")
+
+var ErrInvalidFormat = errors.New("invalid suffix tree format")
+
+/*
+ * WriteTo writes the GST in a compact binary format: nodes, edges, suffix links, payloads, suffix starts and the
+ * number of keys of each index.
+ *
+ * Edge labels are not written one by one: the labels of the edges created while adding a key are slices of the
+ * same rune array, and the sum of the label lengths grows quadratically with the key length. Instead, every
+ * distinct backing array is written once as a rune pool, and each label as a (pool, offset, length) reference,
+ * so that the loaded tree shares its label memory the same way the original tree does.
+ */
+func (t *generalizedSuffixTree) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	// number the nodes in breadth-first order, the root being 0
+	nodes := []*node{t.root}
+	ids := map[*node]int{t.root: 0}
+	for i := 0; i < len(nodes); i++ {
+		for _, e := range nodes[i].edges {
+			if _, ok := ids[e.node]; !ok {
+				ids[e.node] = len(nodes)
+				nodes = append(nodes, e.node)
+			}
+		}
+	}
+
+	// collect the rune pools, a backing array is identified by the address of its last element
+	type poolRef struct {
+		id    int
+		runes []rune // the widest view of the backing array, from the smallest offset to its end
+	}
+	pools := make(map[*rune]*poolRef)
+	order := make([]*poolRef, 0)
+	for _, n := range nodes {
+		for _, e := range n.edges {
+			end := labelEnd(e.label)
+			pool, ok := pools[end]
+			if !ok {
+				pool = &poolRef{id: len(order)}
+				pools[end] = pool
+				order = append(order, pool)
+			}
+			if cap(e.label) > len(pool.runes) {
+				pool.runes = e.label[:cap(e.label)]
+			}
+		}
+	}
+
+	cw.write(serializationMagic)
+	cw.uvarint(uint64(len(order)))
+	for _, pool := range order {
+		cw.uvarint(uint64(len(pool.runes)))
+		for _, r := range pool.runes {
+			cw.varint(int64(r))
+		}
+	}
+
+	cw.uvarint(uint64(len(nodes)))
+	for _, n := range nodes {
+		cw.uvarint(uint64(len(n.data)))
+		for _, idx := range n.data {
+			cw.varint(int64(idx))
+		}
+		cw.uvarint(uint64(len(n.starts)))
+		for _, start := range n.starts {
+			cw.varint(int64(start.index))
+			cw.uvarint(uint64(start.key))
+			cw.uvarint(uint64(start.offset))
+			cw.uvarint(uint64(start.length))
+		}
+
+		suffix := 0
+		if n.suffix != nil {
+			id, ok := ids[n.suffix]
+			if !ok {
+				return cw.n, fmt.Errorf("%w: suffix link to a node outside of the tree", ErrInvalidFormat)
+			}
+			suffix = id + 1
+		}
+		cw.uvarint(uint64(suffix))
+
+		cw.uvarint(uint64(len(n.edges)))
+		for _, e := range n.edges {
+			pool := pools[labelEnd(e.label)]
+			cw.uvarint(uint64(pool.id))
+			cw.uvarint(uint64(len(pool.runes) - cap(e.label)))
+			cw.uvarint(uint64(len(e.label)))
+			cw.uvarint(uint64(ids[e.node]))
+		}
+	}
+
+	// the indexes are sorted, so that the same tree is always written the same way
+	indexes := make([]int, 0, len(t.keyCounts))
+	for index := range t.keyCounts {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	cw.uvarint(uint64(len(indexes)))
+	for _, index := range indexes {
+		cw.varint(int64(index))
+		cw.uvarint(uint64(t.keyCounts[index]))
+	}
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// ReadFrom replaces the content of the GST with the tree read from r, in the format written by WriteTo.
+//
+// Every element takes at least a byte of the input, so the counts larger than the bytes left are rejected before
+// allocating anything. The input is read in memory first when its size is unknown, i.e. r is neither a Seeker
+// nor a reader with a Len method like *bytes.Reader.
+func (t *generalizedSuffixTree) ReadFrom(r io.Reader) (int64, error) {
+	size := inputSize(r)
+	if size < 0 {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return int64(len(data)), err
+		}
+		r, size = bytes.NewReader(data), int64(len(data))
+	}
+	cr := &countingReader{r: bufio.NewReader(r), size: size}
+
+	magic := make([]byte, len(serializationMagic))
+	if _, err := io.ReadFull(cr, magic); err != nil {
+		return cr.n, err
+	}
+	if string(magic) != string(serializationMagic) {
+		return cr.n, fmt.Errorf("%w: unknown header %q", ErrInvalidFormat, magic)
+	}
+
+	pools := make([][]rune, cr.length())
+	for i := range pools {
+		pools[i] = make([]rune, cr.length())
+		for j := range pools[i] {
+			pools[i][j] = rune(cr.varint())
+		}
+	}
+
+	nodes := make([]*node, cr.length())
+	if cr.err == nil && len(nodes) == 0 {
+		return cr.n, fmt.Errorf("%w: the tree has no root", ErrInvalidFormat)
+	}
+	for i := range nodes {
+		nodes[i] = newNode()
+	}
+	for _, n := range nodes {
+		if cr.err != nil {
+			break
+		}
+		if count := cr.length(); count > 0 {
+			n.data = make([]int, count)
+			for i := range n.data {
+				n.data[i] = int(cr.varint())
+			}
+		}
+		if count := cr.length(); count > 0 {
+			n.starts = make([]suffixStart, count)
+			for i := range n.starts {
+				n.starts[i] = suffixStart{index: int(cr.varint()), key: int(cr.uvarint()), offset: int(cr.uvarint()), length: int(cr.uvarint())}
+			}
+		}
+
+		if suffix := cr.uvarint(); suffix > 0 {
+			if suffix > uint64(len(nodes)) {
+				return cr.n, fmt.Errorf("%w: suffix link to unknown node %d", ErrInvalidFormat, suffix-1)
+			}
+			n.suffix = nodes[suffix-1]
+		}
+
+		for count := cr.length(); count > 0 && cr.err == nil; count-- {
+			pool, offset, length, child := cr.uvarint(), cr.uvarint(), cr.uvarint(), cr.uvarint()
+			if cr.err != nil {
+				break
+			}
+			if pool >= uint64(len(pools)) || offset+length > uint64(len(pools[pool])) || length == 0 || child >= uint64(len(nodes)) {
+				return cr.n, fmt.Errorf("%w: edge out of bounds", ErrInvalidFormat)
+			}
+			n.edges = append(n.edges, newEdge(pools[pool][offset:offset+length], nodes[child]))
+		}
+	}
+
+	keyCounts := make(map[int]int)
+	for count := cr.length(); count > 0 && cr.err == nil; count-- {
+		index := int(cr.varint())
+		keyCounts[index] = int(cr.uvarint())
+	}
+
+	if cr.err != nil {
+		if cr.err == io.EOF {
+			cr.err = io.ErrUnexpectedEOF
+		}
+		return cr.n, cr.err
+	}
+
+	t.root = nodes[0]
+	t.activeLeaf = t.root
+	t.keyCounts = keyCounts
+	return cr.n, nil
+}
+
+// LoadGeneralizedSuffixTree reads a GST written by WriteTo.
+func LoadGeneralizedSuffixTree(r io.Reader) (*generalizedSuffixTree, error) {
+	t := NewGeneralizedSuffixTree()
+	if _, err := t.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// inputSize returns the number of bytes left in r, or -1 if it is unknown.
+func inputSize(r io.Reader) int64 {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case io.Seeker:
+		current, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := r.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err := r.Seek(current, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - current
+	}
+	return -1
+}
+
+// labelEnd returns the address of the last element of the array backing the label.
+func labelEnd(label []rune) *rune {
+	return &label[:cap(label)][cap(label)-1]
+}
+
+// countingWriter writes varints and keeps the first error, so that errors only need to be checked once.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+	buf [binary.MaxVarintLen64]byte
+}
+
+func (cw *countingWriter) write(p []byte) {
+	if cw.err != nil {
+		return
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+}
+
+func (cw *countingWriter) uvarint(v uint64) {
+	cw.write(cw.buf[:binary.PutUvarint(cw.buf[:], v)])
+}
+
+func (cw *countingWriter) varint(v int64) {
+	cw.write(cw.buf[:binary.PutVarint(cw.buf[:], v)])
+}
+
+// countingReader reads varints and keeps the first error, so that errors only need to be checked once.
+type countingReader struct {
+	r    *bufio.Reader
+	n    int64
+	size int64 // the number of bytes of the input
+	err  error
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+func (cr *countingReader) ReadByte() (byte, error) {
+	b, err := cr.r.ReadByte()
+	if err == nil {
+		cr.n++
+	}
+	return b, err
+}
+
+func (cr *countingReader) uvarint() uint64 {
+	if cr.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(cr)
+	cr.err = err
+	return v
+}
+
+func (cr *countingReader) varint() int64 {
+	if cr.err != nil {
+		return 0
+	}
+	v, err := binary.ReadVarint(cr)
+	cr.err = err
+	return v
+}
+
+// length reads a count of elements, and rejects the counts larger than the bytes left in the input,
+// each element taking at least a byte
+func (cr *countingReader) length() int {
+	v := cr.uvarint()
+	if cr.err == nil && v > uint64(cr.size-cr.n) {
+		cr.err = fmt.Errorf("%w: length %d is larger than the %d bytes left", ErrInvalidFormat, v, cr.size-cr.n)
+		return 0
+	}
+	return int(v)
+}
@@ -0,0 +1,125 @@
+package suffixtree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSuffixTreeSerializationRoundTrip(t *testing.T) {
+	keys := map[int]string{0: "banana", 1: "apple", 2: "中文app", 3: "bandana", 4: "the quick brown fox jumped over the lazy dog"}
+	tree := NewGeneralizedSuffixTree()
+	for index := 0; index < len(keys); index++ {
+		tree.Put(keys[index], index)
+	}
+
+	var buf bytes.Buffer
+	written, err := tree.WriteTo(&buf)
+	if err != nil {
+		t.Fatal("failed to write the tree: ", err)
+	}
+	if written != int64(buf.Len()) {
+		t.Errorf("WriteTo reported %d bytes, but wrote %d", written, buf.Len())
+	}
+
+	loaded, err := LoadGeneralizedSuffixTree(&buf)
+	if err != nil {
+		t.Fatal("failed to load the tree: ", err)
+	}
+	for _, key := range keys {
+		checkAllSubstrings(t, loaded, keys, key)
+	}
+
+	occurrences := loaded.SearchOccurrences("ana", -1)
+	if fmt.Sprint(occurrences) != fmt.Sprint(tree.SearchOccurrences("ana", -1)) {
+		t.Errorf("occurrences differ after loading: %v", occurrences)
+	}
+
+	// the loaded tree can still be updated
+	loaded.Remove(0)
+	delete(keys, 0)
+	loaded.Put("cabana", 5)
+	keys[5] = "cabana"
+	for _, key := range keys {
+		checkAllSubstrings(t, loaded, keys, key)
+	}
+}
+
+func TestSuffixTreeSerializationSize(t *testing.T) {
+	// labels are written as references to shared rune pools, so the size grows linearly with the keys
+	r := rand.New(rand.NewSource(1))
+	size := func(length int) int {
+		tree := NewGeneralizedSuffixTree()
+		for index := 0; index < 10; index++ {
+			tree.Put(randomKey(r, length, "abcdefghij")+strings.Repeat("z", length/2), index)
+		}
+		var buf bytes.Buffer
+		if _, err := tree.WriteTo(&buf); err != nil {
+			t.Fatal("failed to write the tree: ", err)
+		}
+		return buf.Len()
+	}
+
+	small, large := size(100), size(1000)
+	if large > 20*small {
+		t.Errorf("serialized size grows too fast: %d bytes for 100 runes keys, %d bytes for 1000 runes keys", small, large)
+	}
+}
+
+func TestSuffixTreeSerializationInvalid(t *testing.T) {
+	if _, err := LoadGeneralizedSuffixTree(strings.NewReader("not a tree")); !errors.Is(err, ErrInvalidFormat) {
+		t.Error("expected ErrInvalidFormat, but ", err)
+	}
+
+	tree := NewGeneralizedSuffixTree()
+	tree.Put("banana", 0)
+	var buf bytes.Buffer
+	if _, err := tree.WriteTo(&buf); err != nil {
+		t.Fatal("failed to write the tree: ", err)
+	}
+	truncated := buf.Bytes()[:buf.Len()/2]
+	if _, err := LoadGeneralizedSuffixTree(bytes.NewReader(truncated)); err == nil {
+		t.Error("expected an error when loading a truncated tree")
+	}
+}
+
+func TestSuffixTreeSerializationDeterministic(t *testing.T) {
+	tree := NewGeneralizedSuffixTree()
+	for index, key := range []string{"banana", "apple", "中文app", "bandana", "cabana", "ananas"} {
+		tree.Put(key, index)
+	}
+
+	var first bytes.Buffer
+	if _, err := tree.WriteTo(&first); err != nil {
+		t.Fatal("failed to write the tree: ", err)
+	}
+	for i := 0; i < 10; i++ {
+		var buf bytes.Buffer
+		if _, err := tree.WriteTo(&buf); err != nil {
+			t.Fatal("failed to write the tree: ", err)
+		}
+		if !bytes.Equal(buf.Bytes(), first.Bytes()) {
+			t.Fatal("the same tree was written differently")
+		}
+	}
+}
+
+func TestSuffixTreeSerializationLargeLength(t *testing.T) {
+	// a few bytes claiming billions of runes must be rejected before allocating them
+	corrupt := append([]byte(nil), serializationMagic...)
+	corrupt = binary.AppendUvarint(corrupt, 1)
+	corrupt = binary.AppendUvarint(corrupt, 1<<32)
+	corrupt = append(corrupt, 0, 0, 0)
+
+	// with a known size, and with a reader hiding it
+	for _, r := range []io.Reader{bytes.NewReader(corrupt), io.MultiReader(bytes.NewReader(corrupt))} {
+		if _, err := LoadGeneralizedSuffixTree(r); !errors.Is(err, ErrInvalidFormat) {
+			t.Error("expected ErrInvalidFormat, but ", err)
+		}
+	}
+}
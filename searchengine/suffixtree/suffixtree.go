@@ -1,13 +1,23 @@
 package suffixtree
 
 import (
+	"sort"
 	"strings"
 	"unicode/utf8"
 )
 
 type generalizedSuffixTree struct {
-	root       *node //The root of the suffix tree
-	activeLeaf *node //The last leaf that was added during the update operation
+	root       *node       //The root of the suffix tree
+	activeLeaf *node       //The last leaf that was added during the update operation
+	keyCounts  map[int]int //The number of keys added under each index
+}
+
+// Occurrence describes where a word occurs in one of the keys added to the GST.
+type Occurrence struct {
+	Index   int   // the index the key has been added under
+	Key     int   // the number of the key among the keys added under the index, from 0 in the order they were added
+	Offsets []int // the byte offsets in the key of all the (possibly overlapping) occurrences of the word
+	Count   int   // the number of occurrences, i.e. len(Offsets)
 }
 
 // Search search for the given word within the GST and returns at most the given number of matches.
@@ -20,6 +30,66 @@ func (t *generalizedSuffixTree) Search(word string, numElements int) []int {
 	return node.getData(numElements)
 }
 
+// SearchOccurrences searches for the given word within the GST and returns the occurrences of the word in the keys
+// of at most the given number of indexes, the keys with the most occurrences first.
+// numElments <= 0 get all matches
+func (t *generalizedSuffixTree) SearchOccurrences(word string, numElements int) []Occurrence {
+	occurrences := make([]Occurrence, 0)
+	found := t.searchNode(word)
+	if word == "" || found == nil {
+		return occurrences
+	}
+
+	// the suffixes starting with the word have their start recorded in the subtree of the node the word leads to
+	byKey := make(map[[2]int]int) // (index, key) -> position in occurrences
+	t.walk(found, func(n *node) {
+		for _, start := range n.starts {
+			if start.length < len(word) {
+				// the suffix ends within the edge leading to n, before the word does
+				continue
+			}
+			position, ok := byKey[[2]int{start.index, start.key}]
+			if !ok {
+				position = len(occurrences)
+				byKey[[2]int{start.index, start.key}] = position
+				occurrences = append(occurrences, Occurrence{Index: start.index, Key: start.key})
+			}
+			occurrences[position].Offsets = append(occurrences[position].Offsets, start.offset)
+		}
+	})
+	for i := range occurrences {
+		sort.Ints(occurrences[i].Offsets)
+		occurrences[i].Count = len(occurrences[i].Offsets)
+	}
+
+	sort.Slice(occurrences, func(i, j int) bool {
+		if occurrences[i].Count != occurrences[j].Count {
+			return occurrences[i].Count > occurrences[j].Count
+		}
+		if occurrences[i].Index != occurrences[j].Index {
+			return occurrences[i].Index < occurrences[j].Index
+		}
+		return occurrences[i].Key < occurrences[j].Key
+	})
+
+	// keep the keys of the first numElements indexes, an index may have several keys
+	if numElements <= 0 {
+		return occurrences
+	}
+	indexes := make(map[int]bool)
+	kept := occurrences[:0]
+	for _, occurrence := range occurrences {
+		if !indexes[occurrence.Index] {
+			if len(indexes) == numElements {
+				continue
+			}
+			indexes[occurrence.Index] = true
+		}
+		kept = append(kept, occurrence)
+	}
+	return kept
+}
+
 // searchNode returns the tree node (if present) that corresponds to the given string.
 func (t *generalizedSuffixTree) searchNode(word string) *node {
 	/*
@@ -85,6 +155,115 @@ func (t *generalizedSuffixTree) Put(key string, index int) {
 	if t.activeLeaf.suffix == nil && t.activeLeaf != t.root && t.activeLeaf != s {
 		t.activeLeaf.suffix = s
 	}
+
+	t.addStarts(runes, key, index)
+}
+
+// addStarts records the start offset of every suffix of the key on the node its path leads to, unless the key
+// has already been added under the index.
+func (t *generalizedSuffixTree) addStarts(runes []rune, key string, index int) {
+	if _, ok := t.keyCounts[index]; !ok {
+		t.keyCounts[index] = 0
+	}
+	if key == "" || t.locate(runes).hasStart(index, 0, len(key)) {
+		return
+	}
+
+	number := t.keyCounts[index]
+	t.keyCounts[index]++
+	i := 0
+	for offset := range key {
+		if n := t.locate(runes[i:]); n != nil {
+			n.starts = append(n.starts, suffixStart{index: index, key: number, offset: offset, length: len(key) - offset})
+		}
+		i++
+	}
+}
+
+// locate returns the node the path of a suffix of the keys leads to, or the node below it when the path ends within
+// an edge. The suffix being in the tree, only the first rune of each edge is compared.
+func (t *generalizedSuffixTree) locate(runes []rune) *node {
+	n := t.root
+	for i := 0; i < len(runes); {
+		e := n.getEdge(runes[i])
+		if e == nil {
+			return nil
+		}
+		i += len(e.label)
+		n = e.node
+	}
+	return n
+}
+
+// Remove removes the given index from the GST, so that it can be added again later with a different key.
+//
+// The index is pruned from the payload of every node, the branches left without any payload are cut off,
+// and the nodes left with a single edge and no payload are merged with their parent edge, unless a suffix
+// link points to them. This walks the whole tree.
+func (t *generalizedSuffixTree) Remove(index int) {
+	if _, ok := t.keyCounts[index]; !ok {
+		return
+	}
+	delete(t.keyCounts, index)
+
+	t.prune(t.root, index)
+
+	// nodes targeted by a suffix link must stay, the construction of later keys may follow the link
+	linked := make(map[*node]bool)
+	t.walk(t.root, func(n *node) {
+		if n.suffix != nil {
+			linked[n.suffix] = true
+		}
+	})
+	t.collapse(t.root, linked)
+	t.activeLeaf = t.root
+}
+
+// prune removes the index from the payloads and the suffix starts of the subtree of n, and cuts off the edges leading to
+// empty subtrees. It returns whether the subtree of n still holds any payload.
+func (t *generalizedSuffixTree) prune(n *node, index int) bool {
+	n.removeIndex(index)
+	n.removeStarts(index)
+
+	edges := n.edges[:0]
+	for _, e := range n.edges {
+		if t.prune(e.node, index) {
+			edges = append(edges, e)
+		}
+	}
+	for i := len(edges); i < len(n.edges); i++ {
+		n.edges[i] = nil
+	}
+	n.edges = edges
+
+	return len(n.data) > 0 || len(n.starts) > 0 || len(n.edges) > 0
+}
+
+// collapse merges the nodes of the subtree of n that have no payload, no suffix start, a single edge and no incoming
+// suffix link into the edge leading to them.
+func (t *generalizedSuffixTree) collapse(n *node, linked map[*node]bool) {
+	for _, e := range n.edges {
+		for {
+			child := e.node
+			if len(child.data) > 0 || len(child.starts) > 0 || len(child.edges) != 1 || linked[child] {
+				break
+			}
+			next := child.edges[0]
+			label := make([]rune, 0, len(e.label)+len(next.label))
+			label = append(label, e.label...)
+			e.label = append(label, next.label...)
+			e.node = next.node
+		}
+		t.collapse(e.node, linked)
+	}
+}
+
+// walk calls fn on every node of the subtree of n.
+func (t *generalizedSuffixTree) walk(n *node, fn func(*node)) {
+	fn(n)
+	for _, e := range n.edges {
+		t.walk(e.node, fn)
+	}
 }
 
 /*
@@ -279,5 +458,6 @@ func NewGeneralizedSuffixTree() *generalizedSuffixTree {
 	t := &generalizedSuffixTree{}
 	t.root = newNode()
 	t.activeLeaf = t.root
+	t.keyCounts = make(map[int]int)
 	return t
 }
@@ -2,6 +2,9 @@ package suffixtree
 
 import (
 	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -36,3 +39,340 @@ func printnode(flag string, n *node) {
 		printnode(flag+"\t-", e.node)
 	}
 }
+
+func TestSuffixTreeOccurrences(t *testing.T) {
+	words := []string{"banana", "apple", "中文app", "bandana"}
+	tree := NewGeneralizedSuffixTree()
+	for k, word := range words {
+		tree.Put(word, k)
+	}
+
+	occurrences := tree.SearchOccurrences("ana", -1)
+	if len(occurrences) != 2 {
+		t.Fatal("occurrences len should be 2, but ", len(occurrences))
+	}
+	// "banana" contains two overlapping "ana", "bandana" only one
+	if occurrences[0].Index != 0 || occurrences[0].Count != 2 || occurrences[0].Offsets[0] != 1 || occurrences[0].Offsets[1] != 3 {
+		t.Error("unexpected occurrences of \"ana\" in banana: ", occurrences[0])
+	}
+	if occurrences[1].Index != 3 || occurrences[1].Count != 1 || occurrences[1].Offsets[0] != 4 {
+		t.Error("unexpected occurrences of \"ana\" in bandana: ", occurrences[1])
+	}
+
+	// offsets are byte offsets, so that the match can be sliced out of the key
+	occurrences = tree.SearchOccurrences("app", -1)
+	for _, occurrence := range occurrences {
+		if occurrence.Index == 2 && occurrence.Offsets[0] != len("中文") {
+			t.Error("unexpected offset of \"app\" in 中文app: ", occurrence.Offsets)
+		}
+		for _, offset := range occurrence.Offsets {
+			if words[occurrence.Index][offset:offset+len("app")] != "app" {
+				t.Error("offset does not point to the match: ", occurrence)
+			}
+		}
+	}
+
+	if occurrences := tree.SearchOccurrences("xyz", -1); len(occurrences) != 0 {
+		t.Error("occurrences should be empty, but ", occurrences)
+	}
+}
+
+func TestSuffixTreeOccurrencesLimit(t *testing.T) {
+	tree := NewGeneralizedSuffixTree()
+	tree.Put("an apple", 0)
+	tree.Put("a banana", 1)
+	tree.Put("ananas", 2)
+	tree.Put("bandana", 3)
+	tree.Put("anaconda", 3)
+
+	// the indexes with the most occurrences come first, whatever their order in the tree
+	occurrences := tree.SearchOccurrences("an", 2)
+	if len(occurrences) != 2 || occurrences[0].Index != 1 || occurrences[1].Index != 2 {
+		t.Fatal("expected the occurrences of banana and ananas, but ", occurrences)
+	}
+
+	// the limit is on the indexes, each one with all its keys
+	occurrences = tree.SearchOccurrences("and", 1)
+	if len(occurrences) != 1 || occurrences[0].Index != 3 {
+		t.Fatal("expected the occurrences of bandana, but ", occurrences)
+	}
+	occurrences = tree.SearchOccurrences("an", 3)
+	indexes := make(map[int]int)
+	for _, occurrence := range occurrences {
+		indexes[occurrence.Index]++
+	}
+	if len(indexes) != 3 || indexes[3] != 2 {
+		t.Error("expected the 2 keys of index 3 among 3 indexes, but ", occurrences)
+	}
+}
+
+func TestSuffixTreeRemove(t *testing.T) {
+	tree := NewGeneralizedSuffixTree()
+	tree.Put("banana", 0)
+	tree.Put("bandana", 1)
+	tree.Remove(0)
+
+	if indexes := tree.Search("nan", -1); len(indexes) != 0 {
+		t.Error("indexes should be empty after removing banana, but ", indexes)
+	}
+	if indexes := tree.Search("ana", -1); len(indexes) != 1 || indexes[0] != 1 {
+		t.Error("indexes should be [1], but ", indexes)
+	}
+
+	// re-insert the index with another key
+	tree.Put("cabana", 0)
+	if indexes := tree.Search("cab", -1); len(indexes) != 1 || indexes[0] != 0 {
+		t.Error("indexes should be [0], but ", indexes)
+	}
+}
+
+func TestSuffixTreePutRemoveAgainstBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for iter := 0; iter < 300; iter++ {
+		tree := NewGeneralizedSuffixTree()
+		keys := make(map[int]string)
+
+		for op := 0; op < 20; op++ {
+			index := r.Intn(6)
+			if _, ok := keys[index]; ok && r.Intn(2) == 0 {
+				tree.Remove(index)
+				delete(keys, index)
+			} else if !ok {
+				key := randomKey(r, 8, "abc")
+				tree.Put(key, index)
+				keys[index] = key
+			}
+
+			// check every substring of every key, including the removed ones
+			for _, key := range keys {
+				checkAllSubstrings(t, tree, keys, key)
+			}
+			checkAllSubstrings(t, tree, keys, "abcabc")
+			if t.Failed() {
+				t.Fatalf("mismatch after operation %d with keys %v", op, keys)
+			}
+		}
+	}
+}
+
+func TestSuffixTreeOccurrencesAgainstBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for iter := 0; iter < 200; iter++ {
+		tree := NewGeneralizedSuffixTree()
+		keys := make(map[int][]string)
+
+		for op := 0; op < 15; op++ {
+			index := r.Intn(4)
+			if _, ok := keys[index]; ok && r.Intn(3) == 0 {
+				tree.Remove(index)
+				delete(keys, index)
+			} else {
+				// an index may have several keys, a key added twice is only counted once
+				key := strings.ReplaceAll(randomKey(r, 8, "abc"), "c", "中")
+				tree.Put(key, index)
+				if !containsString(keys[index], key) {
+					keys[index] = append(keys[index], key)
+				}
+			}
+
+			for _, word := range []string{"a", "ab", "ba", "中a", "aab"} {
+				expected := make([]string, 0)
+				for index, indexKeys := range keys {
+					for number, key := range indexKeys {
+						if offsets := bruteForceOffsets(key, word); len(offsets) > 0 {
+							expected = append(expected, fmt.Sprint(index, number, offsets))
+						}
+					}
+				}
+				got := make([]string, 0)
+				for _, occurrence := range tree.SearchOccurrences(word, -1) {
+					got = append(got, fmt.Sprint(occurrence.Index, occurrence.Key, occurrence.Offsets))
+				}
+				sort.Strings(expected)
+				sort.Strings(got)
+				if fmt.Sprint(got) != fmt.Sprint(expected) {
+					t.Fatalf("occurrences of %q with keys %v: expected %v, got %v", word, keys, expected, got)
+				}
+			}
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// bruteForceOffsets returns the byte offsets of all the occurrences of word in key, including the overlapping ones.
+func bruteForceOffsets(key string, word string) []int {
+	offsets := make([]int, 0)
+	for offset := range key {
+		if strings.HasPrefix(key[offset:], word) {
+			offsets = append(offsets, offset)
+		}
+	}
+	return offsets
+}
+
+func TestSuffixTreeSearchResultsAgainstMutations(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for iter := 0; iter < 100; iter++ {
+		tree := NewGeneralizedSuffixTree()
+		keys := make(map[int]string)
+		// the results returned so far, which must not change when the tree does
+		results := make([][]int, 0)
+		expected := make([][]int, 0)
+
+		for op := 0; op < 20; op++ {
+			index := r.Intn(6)
+			if _, ok := keys[index]; ok && r.Intn(2) == 0 {
+				tree.Remove(index)
+				delete(keys, index)
+			} else if !ok {
+				key := randomKey(r, 8, "abc")
+				tree.Put(key, index)
+				keys[index] = key
+			}
+
+			for _, sub := range []string{"a", "b", "ab", "ca"} {
+				// a limit of 0 gets all the matches, as -1 does
+				got := tree.Search(sub, 0)
+				all := tree.Search(sub, -1)
+				if fmt.Sprint(sortedCopy(got)) != fmt.Sprint(sortedCopy(all)) {
+					t.Fatalf("search %q with keys %v: %v with no limit, %v with -1", sub, keys, got, all)
+				}
+				results = append(results, got)
+				expected = append(expected, append([]int(nil), got...))
+			}
+			for i := range results {
+				if fmt.Sprint(results[i]) != fmt.Sprint(expected[i]) {
+					t.Fatalf("result %d changed after operation %d: expected %v, got %v", i, op, expected[i], results[i])
+				}
+			}
+		}
+	}
+}
+
+func sortedCopy(values []int) []int {
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	return sorted
+}
+
+func checkAllSubstrings(t *testing.T, tree *generalizedSuffixTree, keys map[int]string, word string) {
+	runes := []rune(word)
+	for i := 0; i < len(runes); i++ {
+		for j := i + 1; j <= len(runes); j++ {
+			sub := string(runes[i:j])
+			got := append([]int(nil), tree.Search(sub, -1)...)
+			sort.Ints(got)
+
+			expected := make([]int, 0)
+			for index, key := range keys {
+				if strings.Contains(key, sub) {
+					expected = append(expected, index)
+				}
+			}
+			sort.Ints(expected)
+
+			if fmt.Sprint(got) != fmt.Sprint(expected) && !(len(got) == 0 && len(expected) == 0) {
+				t.Errorf("search %q: expected %v, got %v", sub, expected, got)
+			}
+		}
+	}
+}
+
+func randomKey(r *rand.Rand, maxLength int, alphabet string) string {
+	b := make([]byte, 1+r.Intn(maxLength))
+	for i := range b {
+		b[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+func TestSuffixTreeSearchApproximate(t *testing.T) {
+	words := []string{"the quick brown fox", "a hobbit hole", "hobbits", "중국어 中文app"}
+	tree := NewGeneralizedSuffixTree()
+	for k, word := range words {
+		tree.Put(word, k)
+	}
+
+	matches := tree.SearchApproximate("hobit", 1, -1)
+	if len(matches) != 2 || matches[0].Index != 1 || matches[0].Distance != 1 || matches[1].Index != 2 {
+		t.Error("expected the two hobbit keys at distance 1, but ", matches)
+	}
+
+	// exact matches come first
+	matches = tree.SearchApproximate("quack", 1, -1)
+	if len(matches) != 1 || matches[0].Index != 0 || matches[0].Distance != 1 {
+		t.Error("expected \"quick\" at distance 1, but ", matches)
+	}
+	matches = tree.SearchApproximate("brown", 2, -1)
+	if len(matches) == 0 || matches[0].Index != 0 || matches[0].Distance != 0 {
+		t.Error("expected an exact match first, but ", matches)
+	}
+
+	matches = tree.SearchApproximate("中又app", 1, -1)
+	if len(matches) != 1 || matches[0].Index != 3 {
+		t.Error("expected the CJK key at distance 1, but ", matches)
+	}
+
+	if matches := tree.SearchApproximate("zebra", 1, -1); len(matches) != 0 {
+		t.Error("matches should be empty, but ", matches)
+	}
+}
+
+func TestSuffixTreeSearchApproximateAgainstBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for iter := 0; iter < 200; iter++ {
+		tree := NewGeneralizedSuffixTree()
+		keys := make([]string, 1+r.Intn(5))
+		for index := range keys {
+			keys[index] = randomKey(r, 10, "abcd")
+			tree.Put(keys[index], index)
+		}
+		word := randomKey(r, 5, "abcd")
+		maxErrors := r.Intn(3)
+
+		got := make(map[int]int)
+		for _, match := range tree.SearchApproximate(word, maxErrors, -1) {
+			got[match.Index] = match.Distance
+		}
+		for index, key := range keys {
+			// the smallest edit distance between word and a substring of key
+			best := len(word)
+			for i := 0; i <= len(key); i++ {
+				for j := i; j <= len(key); j++ {
+					best = min(best, editDistance(word, key[i:j]))
+				}
+			}
+			distance, ok := got[index]
+			if (best <= maxErrors) != ok || (ok && distance != best) {
+				t.Fatalf("%v: search %q with %d errors, expected distance %d for %q, got %v", keys, word, maxErrors, best, key, got)
+			}
+		}
+	}
+}
+
+func editDistance(a, b string) int {
+	column := make([]int, len(b)+1)
+	for j := range column {
+		column[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		diagonal := column[0]
+		column[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			diagonal, column[j] = column[j], min(column[j]+1, column[j-1]+1, diagonal+cost)
+		}
+	}
+	return column[len(b)]
+}
@@ -0,0 +1,89 @@
+package searchengine
+
+import (
+	nlp "go4search/nlp"
+)
+
+// SYNONYM_WEIGHT is the default weight of the synonyms added to a query, see SetQuerySynonyms.
+const SYNONYM_WEIGHT = 0.8
+
+/**
+ * Expand the queries with synonyms: the terms of the synonyms are added to the query terms with the given weight,
+ * and the words having one-way synonyms are replaced. The index is left unchanged.
+ *
+ * @param synonyms The synonyms, e.g. loaded with nlp.LoadSynonymFile, nil to disable the expansion
+ * @param weight The weight of the synonyms relative to the words of the query, e.g. SYNONYM_WEIGHT
+ */
+func (se *SearchEngine) SetQuerySynonyms(synonyms *nlp.SynonymMap, weight float64) {
+	se.QuerySynonyms = nil
+	if synonyms != nil {
+		se.QuerySynonyms = nlp.NewSynonymFilter(synonyms)
+	}
+	se.SynonymWeight = weight
+}
+
+/**
+ * Index the synonyms of the words of the documents along with them, and index the documents again accordingly.
+ * Since the queries are analyzed as the documents, they are expanded with the same synonyms, with the weight of
+ * their words.
+ *
+ * @param synonyms The synonyms, e.g. loaded with nlp.LoadSynonymFile, nil to remove them from the index
+ */
+func (se *SearchEngine) SetIndexSynonyms(synonyms *nlp.SynonymMap) {
+	var filter *nlp.SynonymFilter
+	if synonyms != nil {
+		filter = nlp.NewSynonymFilter(synonyms)
+	}
+	se.SetAnalyzer(FIELD_CONTENT, se.Analyzer(FIELD_CONTENT).WithSynonyms(filter))
+	se.Reindex()
+}
+
+// setSynonymOptions sets the synonyms of the options before the documents are indexed.
+// The analyzers given to NewSearchEngine are left unchanged.
+func (se *SearchEngine) setSynonymOptions(opts EngineOptions) {
+	if opts.IndexSynonyms != nil {
+		analyzers := make(map[string]*nlp.Analyzer, len(se.Analyzers)+1)
+		for field, analyzer := range se.Analyzers {
+			analyzers[field] = analyzer
+		}
+		se.Analyzers = analyzers
+		se.SetAnalyzer(FIELD_CONTENT, se.Analyzer(FIELD_CONTENT).WithSynonyms(nlp.NewSynonymFilter(opts.IndexSynonyms)))
+	}
+	if opts.QuerySynonyms != nil {
+		weight := opts.SynonymWeight
+		if weight == 0 {
+			weight = SYNONYM_WEIGHT
+		}
+		se.SetQuerySynonyms(opts.QuerySynonyms, weight)
+	}
+}
+
+/**
+ * Analyze the free text of a query (see analyzeQueryText), and expand it with the query synonyms if any.
+ * The terms of the synonyms which are not terms of the query itself are weighted with SynonymWeight.
+ *
+ * @param analyzer The analyzer of the field
+ * @param text The free text of the query
+ * @param languages The ISO 639-1 codes of the `lang:` filters of the query
+ * @return []queryTerm
+ */
+func (se *SearchEngine) expandQueryText(analyzer *nlp.Analyzer, text string, languages []string) []queryTerm {
+	terms := se.analyzeQueryText(analyzer, text, languages)
+	if se.QuerySynonyms == nil {
+		return newQueryTerms(terms, 1.0)
+	}
+
+	original := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		original[term] = true
+	}
+	expanded := make([]queryTerm, 0)
+	for _, term := range se.analyzeQueryText(analyzer.WithSynonyms(se.QuerySynonyms), text, languages) {
+		weight := se.SynonymWeight
+		if original[term] {
+			weight = 1.0
+		}
+		expanded = append(expanded, queryTerm{token: term, weight: weight})
+	}
+	return expanded
+}
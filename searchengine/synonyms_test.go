@@ -0,0 +1,96 @@
+package searchengine
+
+import (
+	"strings"
+	"testing"
+
+	nlp "go4search/nlp"
+)
+
+var synonymTestEngine = testEngine{
+	contents: []string{
+		"A comfortable sofa in the living room",
+		"The couch was too small for the room",
+		"Flights to the United States are expensive",
+		"The USA won the game",
+	},
+}
+
+func loadTestSynonyms(t *testing.T) *nlp.SynonymMap {
+	synonyms, err := nlp.LoadSynonyms(strings.NewReader("couch, sofa\nusa, united states\nflat => apartment"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return synonyms
+}
+
+func TestSearchQuerySynonyms(t *testing.T) {
+	se, synonyms := synonymTestEngine.build(), loadTestSynonyms(t)
+	if results := se.Search("sofa", 10); len(results) != 1 {
+		t.Errorf("Expected document 0, got %v", results)
+	}
+
+	se.SetQuerySynonyms(synonyms, SYNONYM_WEIGHT)
+	// the synonyms match with a lower weight than the words of the query
+	results := se.Search("sofa", 10)
+	if len(results) != 2 || results[0].ID != 0 || results[1].ID != 1 {
+		t.Errorf("Expected documents 0 and 1, got %v", results)
+	}
+	// the multi-word synonyms
+	for _, query := range []string{"usa", "united states"} {
+		if results := se.Search(query, 10); len(results) != 2 || results[0].ID+results[1].ID != 5 {
+			t.Errorf("%s: expected documents 2 and 3, got %v", query, results)
+		}
+	}
+	// the index is left unchanged
+	if _, ok := se.Index["couch"]; !ok || len(se.Index["sofa"]) != 1 {
+		t.Errorf("Expected the index to be unchanged, got %v and %v", se.Index["couch"], se.Index["sofa"])
+	}
+
+	se.SetQuerySynonyms(nil, SYNONYM_WEIGHT)
+	if results := se.Search("sofa", 10); len(results) != 1 {
+		t.Errorf("Expected document 0 without synonyms, got %v", results)
+	}
+}
+
+func TestSearchIndexSynonyms(t *testing.T) {
+	se, synonyms := synonymTestEngine.build(), loadTestSynonyms(t)
+	se.SetIndexSynonyms(synonyms)
+
+	if !equalSlice(se.Index["sofa"], []int{0, 1}) || !equalSlice(se.Index["usa"], []int{2, 3}) {
+		t.Errorf("Expected the synonyms to be indexed, got %v and %v", se.Index["sofa"], se.Index["usa"])
+	}
+	for _, query := range []string{"couch", "sofa"} {
+		if results := se.Search(query, 10); len(results) != 2 {
+			t.Errorf("%s: expected documents 0 and 1, got %v", query, results)
+		}
+	}
+
+	se.SetIndexSynonyms(nil)
+	if !equalSlice(se.Index["sofa"], []int{0}) {
+		t.Errorf("Expected the synonyms to be removed from the index, got %v", se.Index["sofa"])
+	}
+}
+
+func TestNewSearchEngineSynonymOptions(t *testing.T) {
+	synonyms := loadTestSynonyms(t)
+	engine := synonymTestEngine
+	engine.analyzer = newTestAnalyzer()
+	engine.options = EngineOptions{IndexSynonyms: synonyms}
+	se := engine.build()
+	if !equalSlice(se.Index["sofa"], []int{0, 1}) {
+		t.Errorf("Expected the synonyms to be indexed, got %v", se.Index["sofa"])
+	}
+	if se.Analyzer(FIELD_CONTENT) == engine.analyzer {
+		t.Errorf("Expected the analyzer of the caller to be left unchanged")
+	}
+
+	engine.options = EngineOptions{QuerySynonyms: synonyms}
+	se = engine.build()
+	if se.QuerySynonyms == nil || se.SynonymWeight != SYNONYM_WEIGHT || len(se.Index["sofa"]) != 1 {
+		t.Errorf("Expected the query synonyms with the weight %v, got %v", SYNONYM_WEIGHT, se.SynonymWeight)
+	}
+	if results := se.Search("sofa", 10); len(results) != 2 {
+		t.Errorf("Expected documents 0 and 1, got %v", results)
+	}
+}
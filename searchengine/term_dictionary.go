@@ -0,0 +1,384 @@
+package searchengine
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	nlp "go4search/nlp"
+)
+
+// MAX_EXPANSIONS is the maximum number of index terms a single prefix or wildcard pattern may expand into.
+const MAX_EXPANSIONS = 64
+
+var ErrPatternTooBroad = errors.New("pattern is too broad")
+
+// TermDictionary keeps the terms of an InvertedIndex in lexicographic order, so that prefix and wildcard
+// patterns can be expanded by scanning a narrow range of terms instead of the whole index.
+// The Hangul terms are also kept by their jamo and by their initial consonants (see nlp.DecomposeHangul and nlp.Chosung).
+type TermDictionary struct {
+	terms     []string            // sorted terms
+	reversed  []string            // sorted reversed terms, used for patterns with a leading wildcard (e.g. `*ness`)
+	jamo      []string            // sorted jamo of the Hangul terms, used for the Korean prefixes and fuzzy terms
+	jamoTerms map[string][]string // the sorted Hangul terms of each jamo decomposition, e.g. `안` and `아ㄴ` for ㅇㅏㄴ
+	chosung   []chosungTerm       // the Hangul terms sorted by their initial consonants, used for the chosung patterns
+}
+
+// chosungTerm is a Hangul term and its initial consonants, e.g. `아이유` and `ㅇㅇㅇ`.
+type chosungTerm struct {
+	chosung string
+	term    string
+}
+
+/**
+ * Build a term dictionary from all the tokens stored in the inverted index.
+ *
+ * @param index An inverted index
+ * @return *TermDictionary
+ */
+func NewTermDictionary(index InvertedIndex) *TermDictionary {
+	td := &TermDictionary{
+		terms:     make([]string, 0, len(index)),
+		reversed:  make([]string, 0, len(index)),
+		jamo:      make([]string, 0),
+		jamoTerms: make(map[string][]string),
+		chosung:   make([]chosungTerm, 0),
+	}
+	for term := range index {
+		td.terms = append(td.terms, term)
+		td.reversed = append(td.reversed, reverseString(term))
+		if nlp.ContainsHangul(term) {
+			jamo := nlp.DecomposeHangul(term)
+			if _, ok := td.jamoTerms[jamo]; !ok {
+				td.jamo = append(td.jamo, jamo)
+			}
+			td.jamoTerms[jamo] = append(td.jamoTerms[jamo], term)
+			td.chosung = append(td.chosung, chosungTerm{chosung: nlp.Chosung(term), term: term})
+		}
+	}
+	sort.Strings(td.terms)
+	sort.Strings(td.reversed)
+	sort.Strings(td.jamo)
+	for _, terms := range td.jamoTerms {
+		sort.Strings(terms)
+	}
+	sort.Slice(td.chosung, func(i, j int) bool {
+		return td.chosung[i].less(td.chosung[j])
+	})
+	return td
+}
+
+func (c chosungTerm) less(other chosungTerm) bool {
+	if c.chosung != other.chosung {
+		return c.chosung < other.chosung
+	}
+	return c.term < other.term
+}
+
+// add inserts a new term of the index into the dictionary, keeping its terms sorted.
+func (td *TermDictionary) add(term string) {
+	if td.contains(term) {
+		return
+	}
+	td.terms = insertSorted(td.terms, term)
+	td.reversed = insertSorted(td.reversed, reverseString(term))
+	if nlp.ContainsHangul(term) {
+		jamo := nlp.DecomposeHangul(term)
+		if _, ok := td.jamoTerms[jamo]; !ok {
+			td.jamo = insertSorted(td.jamo, jamo)
+		}
+		td.jamoTerms[jamo] = insertSorted(td.jamoTerms[jamo], term)
+
+		entry := chosungTerm{chosung: nlp.Chosung(term), term: term}
+		i := sort.Search(len(td.chosung), func(k int) bool { return !td.chosung[k].less(entry) })
+		td.chosung = append(td.chosung, chosungTerm{})
+		copy(td.chosung[i+1:], td.chosung[i:])
+		td.chosung[i] = entry
+	}
+}
+
+// insertSorted inserts a key into sorted keys.
+func insertSorted(keys []string, key string) []string {
+	i := sort.SearchStrings(keys, key)
+	keys = append(keys, "")
+	copy(keys[i+1:], keys[i:])
+	keys[i] = key
+	return keys
+}
+
+// Len returns the number of terms in the dictionary.
+func (td *TermDictionary) Len() int {
+	return len(td.terms)
+}
+
+/**
+ * Return all the terms that start with the given prefix.
+ * A Hangul prefix is matched by its jamo, so that the syllable being typed matches the syllables starting with its
+ * jamo, e.g. `아잉` (ㅇㅏㅇㅣㅇ) matches `아이유` (ㅇㅏㅇㅣㅇㅠ) for autocompletion.
+ *
+ * @param prefix A term prefix
+ * @param maxExpansions The maximum number of terms to return. Use <=0 for no limit.
+ * @return ([]string, error) the matching terms, ErrPatternTooBroad if more than maxExpansions terms match
+ */
+func (td *TermDictionary) ExpandPrefix(prefix string, maxExpansions int) ([]string, error) {
+	if prefix == "" {
+		return nil, fmt.Errorf("%w: empty prefix matches every term", ErrPatternTooBroad)
+	}
+	if nlp.ContainsHangul(prefix) {
+		decompositions, err := collectRange(td.jamo, nlp.DecomposeHangul(prefix), false, nil, maxExpansions, prefix)
+		if err != nil {
+			return nil, err
+		}
+		expansions := make([]string, 0, len(decompositions))
+		for _, jamo := range decompositions {
+			expansions = append(expansions, td.jamoTerms[jamo]...)
+		}
+		if maxExpansions > 0 && len(expansions) > maxExpansions {
+			return nil, tooManyExpansions(prefix, maxExpansions)
+		}
+		sort.Strings(expansions)
+		return expansions, nil
+	}
+	return collectRange(td.terms, prefix, false, nil, maxExpansions, prefix)
+}
+
+/**
+ * Return all the terms that match the given wildcard pattern.
+ * `*` matches any sequence of characters (including the empty one), and `?` matches exactly one character.
+ * The literal prefix of the pattern narrows the scan on the sorted terms. If the pattern starts with a wildcard,
+ * the literal suffix is used on the reversed terms instead. A Hangul prefix pattern (e.g. `아잉*`) is expanded as
+ * ExpandPrefix, by its jamo.
+ *
+ * @param pattern A wildcard pattern such as `hobb*`, `*ness` or `b?g`
+ * @param maxExpansions The maximum number of terms to return. Use <=0 for no limit.
+ * @return ([]string, error) the matching terms, ErrPatternTooBroad if more than maxExpansions terms match
+ */
+func (td *TermDictionary) ExpandWildcard(pattern string, maxExpansions int) ([]string, error) {
+	if !strings.ContainsAny(pattern, "*?") {
+		if td.contains(pattern) {
+			return []string{pattern}, nil
+		}
+		return []string{}, nil
+	}
+	if strings.Trim(pattern, "*?") == "" {
+		return nil, fmt.Errorf("%w: %q has no literal characters", ErrPatternTooBroad, pattern)
+	}
+
+	match := func(term string) bool { return matchWildcard(pattern, term) }
+
+	prefix := pattern[:strings.IndexAny(pattern, "*?")]
+	if prefix+"*" == pattern && nlp.ContainsHangul(prefix) {
+		return td.ExpandPrefix(prefix, maxExpansions)
+	}
+	if prefix != "" {
+		return collectRange(td.terms, prefix, false, match, maxExpansions, pattern)
+	}
+
+	suffix := pattern[strings.LastIndexAny(pattern, "*?")+1:]
+	if suffix != "" {
+		return collectRange(td.reversed, reverseString(suffix), true, match, maxExpansions, pattern)
+	}
+
+	// both ends are wildcards (e.g. `*obb*`), so every term has to be checked
+	expansions := make([]string, 0)
+	for _, term := range td.terms {
+		if !match(term) {
+			continue
+		}
+		if maxExpansions > 0 && len(expansions) >= maxExpansions {
+			return nil, tooManyExpansions(pattern, maxExpansions)
+		}
+		expansions = append(expansions, term)
+	}
+	return expansions, nil
+}
+
+// FuzzyExpansion is a term of the dictionary matched by a fuzzy term, with its edit distance to the fuzzy term.
+type FuzzyExpansion struct {
+	Term     string
+	Distance int
+}
+
+/**
+ * Return the terms within the given edit distance of the term, closest first.
+ * A Levenshtein automaton of the term is run along the sorted terms. The automaton states of the common prefix
+ * of two consecutive terms are reused, and all the terms sharing a prefix that cannot match anymore are skipped.
+ * A Hangul term is compared with the Hangul terms by their jamo, so that a wrong jamo is a single edit rather than
+ * a whole syllable, e.g. `아이우` is 1 edit away from `아이유` (ㅇㅏㅇㅣㅇㅜ and ㅇㅏㅇㅣㅇㅠ).
+ *
+ * @param term A term
+ * @param maxDistance The maximum edit distance
+ * @param maxExpansions The maximum number of terms to return, the farthest ones are dropped. Use <=0 for no limit.
+ * @return []FuzzyExpansion
+ */
+func (td *TermDictionary) ExpandFuzzy(term string, maxDistance int, maxExpansions int) []FuzzyExpansion {
+	var expansions []FuzzyExpansion
+	if nlp.ContainsHangul(term) {
+		expansions = make([]FuzzyExpansion, 0)
+		for _, expansion := range expandFuzzyTerms(td.jamo, nlp.DecomposeHangul(term), maxDistance) {
+			for _, hangulTerm := range td.jamoTerms[expansion.Term] {
+				expansions = append(expansions, FuzzyExpansion{Term: hangulTerm, Distance: expansion.Distance})
+			}
+		}
+	} else {
+		expansions = expandFuzzyTerms(td.terms, term, maxDistance)
+	}
+
+	sort.SliceStable(expansions, func(i, j int) bool {
+		return expansions[i].Distance < expansions[j].Distance
+	})
+	if maxExpansions > 0 && len(expansions) > maxExpansions {
+		expansions = expansions[:maxExpansions]
+	}
+	return expansions
+}
+
+// expandFuzzyTerms returns the sorted terms within the given edit distance of the term, in their order, see ExpandFuzzy.
+func expandFuzzyTerms(terms []string, term string, maxDistance int) []FuzzyExpansion {
+	automaton := nlp.NewLevenshteinAutomaton(term, maxDistance)
+	expansions := make([]FuzzyExpansion, 0)
+
+	// states[i] is the automaton state after consuming the first i runes of prefix
+	states := []nlp.LevenshteinState{automaton.Start()}
+	prefix := []rune{}
+
+	for i := 0; i < len(terms); {
+		runes := []rune(terms[i])
+
+		// reuse the states of the prefix shared with the previous term
+		common := 0
+		for common < len(prefix) && common < len(runes) && prefix[common] == runes[common] {
+			common++
+		}
+		states = states[:common+1]
+
+		dead := -1
+		for j := common; j < len(runes); j++ {
+			state := automaton.Step(states[j], runes[j])
+			if !automaton.CanMatch(state) {
+				dead = j
+				break
+			}
+			states = append(states, state)
+		}
+		prefix = runes[:len(states)-1]
+
+		if dead < 0 {
+			if state := states[len(states)-1]; automaton.IsMatch(state) {
+				expansions = append(expansions, FuzzyExpansion{Term: terms[i], Distance: automaton.Distance(state)})
+			}
+			i++
+			continue
+		}
+
+		// no term starting with runes[:dead+1] can match, so skip all of them
+		deadPrefix := string(runes[:dead+1])
+		i += sort.Search(len(terms)-i, func(k int) bool {
+			return !strings.HasPrefix(terms[i+k], deadPrefix)
+		})
+	}
+	return expansions
+}
+
+/**
+ * Return the Hangul terms whose syllables start with the given initial consonants (chosung), e.g. `아이유` for `ㅇㅇㅇ`.
+ * With a trailing `*`, the terms whose initial consonants start with the pattern are returned as well,
+ * e.g. `아이유` and `아이폰` for `ㅇㅇ*`.
+ *
+ * @param pattern The initial consonants, e.g. `ㅇㅇㅇ`, optionally followed by `*`
+ * @param maxExpansions The maximum number of terms to return. Use <=0 for no limit.
+ * @return ([]string, error) the matching terms, ErrPatternTooBroad if more than maxExpansions terms match
+ */
+func (td *TermDictionary) ExpandChosung(pattern string, maxExpansions int) ([]string, error) {
+	chosung, isPrefix := strings.CutSuffix(pattern, "*")
+	chosung = nlp.Chosung(chosung)
+	if chosung == "" {
+		return nil, fmt.Errorf("%w: %q has no initial consonant", ErrPatternTooBroad, pattern)
+	}
+
+	expansions := make([]string, 0)
+	i := sort.Search(len(td.chosung), func(k int) bool { return td.chosung[k].chosung >= chosung })
+	for ; i < len(td.chosung) && strings.HasPrefix(td.chosung[i].chosung, chosung); i++ {
+		if !isPrefix && td.chosung[i].chosung != chosung {
+			break
+		}
+		if maxExpansions > 0 && len(expansions) >= maxExpansions {
+			return nil, tooManyExpansions(pattern, maxExpansions)
+		}
+		expansions = append(expansions, td.chosung[i].term)
+	}
+	sort.Strings(expansions)
+	return expansions, nil
+}
+
+func (td *TermDictionary) contains(term string) bool {
+	i := sort.SearchStrings(td.terms, term)
+	return i < len(td.terms) && td.terms[i] == term
+}
+
+// collectRange scans the sorted keys that start with prefix, and collects the terms accepted by match.
+// If reversed is true, the keys are reversed terms and are turned back into terms before matching.
+func collectRange(keys []string, prefix string, reversed bool, match func(string) bool, maxExpansions int, pattern string) ([]string, error) {
+	expansions := make([]string, 0)
+	for i := sort.SearchStrings(keys, prefix); i < len(keys) && strings.HasPrefix(keys[i], prefix); i++ {
+		term := keys[i]
+		if reversed {
+			term = reverseString(term)
+		}
+		if match != nil && !match(term) {
+			continue
+		}
+		if maxExpansions > 0 && len(expansions) >= maxExpansions {
+			return nil, tooManyExpansions(pattern, maxExpansions)
+		}
+		expansions = append(expansions, term)
+	}
+	if reversed {
+		// terms collected from the reversed keys are not in lexicographic order
+		sort.Strings(expansions)
+	}
+	return expansions, nil
+}
+
+func tooManyExpansions(pattern string, maxExpansions int) error {
+	return fmt.Errorf("%w: %q matches more than %d terms", ErrPatternTooBroad, pattern, maxExpansions)
+}
+
+// matchWildcard reports whether the term matches the pattern, where `*` matches any run of runes and `?` a single rune.
+func matchWildcard(pattern string, term string) bool {
+	p := []rune(pattern)
+	t := []rune(term)
+
+	// iterative matching with backtracking to the last `*`
+	pi, ti := 0, 0
+	star, mark := -1, 0
+	for ti < len(t) {
+		if pi < len(p) && (p[pi] == '?' || p[pi] == t[ti]) {
+			pi++
+			ti++
+		} else if pi < len(p) && p[pi] == '*' {
+			star = pi
+			mark = ti
+			pi++
+		} else if star != -1 {
+			pi = star + 1
+			mark++
+			ti = mark
+		} else {
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+func reverseString(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
@@ -0,0 +1,255 @@
+package searchengine
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	documents "go4search/documents"
+	nlp "go4search/nlp"
+)
+
+func buildTestDictionary() *TermDictionary {
+	docs := []documents.Document{
+		{ID: 0, Content: "In a hole in the ground there lived a hobbit"},
+		{ID: 1, Content: "hobbits love happiness and kindness"},
+		{ID: 2, Content: "a big bag with a bug"},
+	}
+	index, _ := BuildInvertedIndex(docs, false)
+	return NewTermDictionary(index)
+}
+
+func TestTermDictionaryExpandPrefix(t *testing.T) {
+	td := buildTestDictionary()
+
+	terms, err := td.ExpandPrefix("hobb", MAX_EXPANSIONS)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"hobbit", "hobbits"}
+	if !equalStrings(terms, expected) {
+		t.Errorf("Expected %v, got %v", expected, terms)
+	}
+}
+
+func TestTermDictionaryExpandWildcard(t *testing.T) {
+	td := buildTestDictionary()
+
+	testCases := map[string][]string{
+		"hobb*":   {"hobbit", "hobbits"},
+		"*ness":   {"happiness", "kindness"},
+		"b?g":     {"bag", "big", "bug"},
+		"*o*e":    {"hole", "love"},
+		"h*s":     {"happiness", "hobbits"},
+		"ground":  {"ground"},
+		"grounds": {},
+	}
+	for pattern, expected := range testCases {
+		terms, err := td.ExpandWildcard(pattern, MAX_EXPANSIONS)
+		if err != nil {
+			t.Errorf("Unexpected error for pattern %s: %v", pattern, err)
+			continue
+		}
+		if !equalStrings(terms, expected) {
+			t.Errorf("Mismatched terms for pattern %s. Expected %v, got %v", pattern, expected, terms)
+		}
+	}
+}
+
+func TestTermDictionaryPatternTooBroad(t *testing.T) {
+	td := buildTestDictionary()
+
+	for _, pattern := range []string{"*", "?*", "b*"} {
+		maxExpansions := 2
+		if _, err := td.ExpandWildcard(pattern, maxExpansions); !errors.Is(err, ErrPatternTooBroad) {
+			t.Errorf("Expected ErrPatternTooBroad for pattern %s, got %v", pattern, err)
+		}
+	}
+}
+
+func TestTermDictionaryExpandFuzzy(t *testing.T) {
+	td := buildTestDictionary()
+
+	expansions := td.ExpandFuzzy("hobit", 1, MAX_EXPANSIONS)
+	if len(expansions) != 1 || expansions[0].Term != "hobbit" || expansions[0].Distance != 1 {
+		t.Errorf("Unexpected expansions %v", expansions)
+	}
+
+	expansions = td.ExpandFuzzy("bog", 1, MAX_EXPANSIONS)
+	terms := make([]string, 0)
+	for _, expansion := range expansions {
+		terms = append(terms, expansion.Term)
+	}
+	expected := []string{"bag", "big", "bug"}
+	if !equalStrings(terms, expected) {
+		t.Errorf("Expected %v, got %v", expected, terms)
+	}
+
+	// closest terms come first, and the farthest ones are dropped
+	expansions = td.ExpandFuzzy("hobbits", 2, 2)
+	if len(expansions) != 2 || expansions[0].Term != "hobbits" || expansions[1].Term != "hobbit" {
+		t.Errorf("Unexpected expansions %v", expansions)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	parsed, err := parseQuery("Where is the Hobb* hole? hobit~1 drem~")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if parsed.text != "Where is the hole?" {
+		t.Errorf("Unexpected free text %q", parsed.text)
+	}
+	if !equalStrings(parsed.wildcards, []string{"hobb*"}) {
+		t.Errorf("Unexpected wildcards %v", parsed.wildcards)
+	}
+	expectedFuzzy := []fuzzyTerm{{term: "hobit", distance: 1}, {term: "drem", distance: MAX_EDIT_DISTANCE}}
+	if len(parsed.fuzzy) != len(expectedFuzzy) || parsed.fuzzy[0] != expectedFuzzy[0] || parsed.fuzzy[1] != expectedFuzzy[1] {
+		t.Errorf("Expected fuzzy terms %v, got %v", expectedFuzzy, parsed.fuzzy)
+	}
+
+	parsed, err = parseQuery(`"hobbit hole" in the "ground" "`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !equalStrings(parsed.phrases, []string{"hobbit hole", "ground"}) || parsed.text != `in the "` {
+		t.Errorf("Unexpected phrases %v and free text %q", parsed.phrases, parsed.text)
+	}
+
+	parsed, err = parseQuery("ㅇㅇㅇ ㅇㅇ* 아이유")
+	if err != nil || !equalStrings(parsed.chosung, []string{"ㅇㅇㅇ", "ㅇㅇ*"}) || parsed.text != "아이유" {
+		t.Errorf("Unexpected chosung patterns %v and free text %q, %v", parsed.chosung, parsed.text, err)
+	}
+
+	if _, err := parseQuery("hobit~3"); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("Expected ErrInvalidQuery, got %v", err)
+	}
+}
+
+func TestTermDictionaryHangul(t *testing.T) {
+	docs := []documents.Document{
+		{ID: 0, Content: "아이유 노래"},
+		{ID: 1, Content: "아이폰 출시"},
+		{ID: 2, Content: "안녕 아이"},
+	}
+	index, _ := BuildInvertedIndex(docs, false)
+	td := NewTermDictionary(index)
+
+	// the syllable being typed matches the syllables starting with its jamo
+	prefixes := map[string][]string{
+		"아이*":  {"아이", "아이유", "아이폰"},
+		"아잉*":  {"아이유"},
+		"아ㅇ*":  {"아이", "아이유", "아이폰"},
+		"안*":   {"안녕"},
+		"아이유*": {"아이유"},
+	}
+	for pattern, expected := range prefixes {
+		terms, err := td.ExpandWildcard(pattern, MAX_EXPANSIONS)
+		if err != nil || !equalStrings(terms, expected) {
+			t.Errorf("%s: expected %v, got %v, %v", pattern, expected, terms, err)
+		}
+	}
+
+	// a wrong jamo is a single edit
+	expansions := td.ExpandFuzzy("아이우", 1, MAX_EXPANSIONS)
+	if len(expansions) != 1 || expansions[0].Term != "아이유" || expansions[0].Distance != 1 {
+		t.Errorf("Unexpected expansions %v", expansions)
+	}
+	expansions = td.ExpandFuzzy("노레", 1, MAX_EXPANSIONS)
+	if len(expansions) != 1 || expansions[0].Term != "노래" {
+		t.Errorf("Unexpected expansions %v", expansions)
+	}
+
+	chosung := map[string][]string{
+		"ㅇㅇㅇ":  {"아이유"},
+		"ㅇㅇㅍ":  {"아이폰"},
+		"ㄴㄹ":   {"노래"},
+		"ㅇㅇ":   {"아이"},
+		"ㅇㅇ*":  {"아이", "아이유", "아이폰"},
+		"ㅎㅎ":   {},
+		"ㅇㅇㅇㅇ": {},
+	}
+	for pattern, expected := range chosung {
+		terms, err := td.ExpandChosung(pattern, MAX_EXPANSIONS)
+		if err != nil || !equalStrings(terms, expected) {
+			t.Errorf("%s: expected %v, got %v, %v", pattern, expected, terms, err)
+		}
+	}
+	if _, err := td.ExpandChosung("ㅇ*", 2); !errors.Is(err, ErrPatternTooBroad) {
+		t.Errorf("Expected ErrPatternTooBroad, got %v", err)
+	}
+}
+
+func TestTermDictionaryHangulKeystrokes(t *testing.T) {
+	td := NewTermDictionary(InvertedIndex{"닭": {0}, "과자": {0}, "달": {1}, "안": {1}, "아ㄴ": {2}})
+
+	// the compound jamo are typed in two keystrokes, so the syllable being typed is a prefix of the compound one
+	prefixes := map[string][]string{
+		"달*": {"달", "닭"},
+		"고*": {"과자"},
+		"과*": {"과자"},
+		// the terms sharing a decomposition are all expanded, once
+		"안*": {"아ㄴ", "안"},
+	}
+	for pattern, expected := range prefixes {
+		terms, err := td.ExpandWildcard(pattern, MAX_EXPANSIONS)
+		if err != nil || !equalStrings(terms, expected) {
+			t.Errorf("%s: expected %v, got %v, %v", pattern, expected, terms, err)
+		}
+	}
+	if _, err := td.ExpandPrefix("안", 1); !errors.Is(err, ErrPatternTooBroad) {
+		t.Errorf("Expected ErrPatternTooBroad, got %v", err)
+	}
+
+	// a missing keystroke of a compound final consonant is a single edit
+	expansions := td.ExpandFuzzy("닥", 1, MAX_EXPANSIONS)
+	terms := make([]string, 0, len(expansions))
+	for _, expansion := range expansions {
+		terms = append(terms, expansion.Term)
+	}
+	sort.Strings(terms)
+	if !equalStrings(terms, []string{"달", "닭"}) {
+		t.Errorf("Expected 달 and 닭, got %v", expansions)
+	}
+
+	// the terms added later are kept with the terms sharing their decomposition
+	td.add("안")
+	td.add("앉")
+	if terms, err := td.ExpandPrefix("안", MAX_EXPANSIONS); err != nil || !equalStrings(terms, []string{"아ㄴ", "안", "앉"}) {
+		t.Errorf("Expected 아ㄴ, 안 and 앉, got %v, %v", terms, err)
+	}
+}
+
+// Helper function to check if two string slices are equal
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTermDictionaryUpdates(t *testing.T) {
+	docs := []documents.Document{
+		{ID: 0, Content: "In a hole in the ground there lived a hobbit"},
+		{ID: 1, Content: "I have a dream that one day this nation will rise up"},
+	}
+	se := NewSearchEngine(docs, map[string]*nlp.Analyzer{FIELD_CONTENT: nlp.NewWhitespaceAnalyzer()})
+	dictionary := se.Dictionary
+
+	// the searches do not rebuild the dictionary
+	se.Search("hobb* hobit~1", 10)
+	if se.Dictionary != dictionary {
+		t.Fatalf("Expected the searches not to modify the search engine")
+	}
+
+	// the terms of a new document are added to the dictionary
+	se.AddNewDocument(documents.Document{ID: 2, Content: "the hobbit met a dragon", Language: "en"})
+	if terms, _ := se.TermDictionary().ExpandPrefix("drag", MAX_EXPANSIONS); !equalStrings(terms, []string{"dragon"}) {
+		t.Errorf("Expected [dragon], got %v", terms)
+	}
+}